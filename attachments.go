@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+// Max size in bytes of an attachment the bot is willing to download
+const maxAttachmentSize = 64 * 1024
+
+// Max size in bytes of a program given as an attached file
+const maxProgramAttachmentSize = 32 * 1024
+
+// Extensions of the attached files that hold Brainfuck programs
+var programExtensions = []string{".bf", ".b"}
+
+var attachmentClient = &http.Client{Timeout: 10 * time.Second}
+
+// fetchAttachment downloads the contents of a message attachment.
+// Attachments bigger than maxAttachmentSize are refused.
+func fetchAttachment(a *dgo.MessageAttachment) ([]byte, error) {
+	return fetchAttachmentLimited(a, maxAttachmentSize)
+}
+
+// fetchAttachmentLimited downloads the contents of a message attachment,
+// refusing attachments bigger than maxSize bytes
+func fetchAttachmentLimited(a *dgo.MessageAttachment, maxSize int) ([]byte, error) {
+	if a.Size > maxSize {
+		return nil, fmt.Errorf("attachment %v has %v bytes, but the maximum allowed is %v bytes", a.Filename, a.Size, maxSize)
+	}
+
+	resp, err := attachmentClient.Get(a.URL)
+	if err != nil {
+		return nil, fmt.Errorf("could not download attachment %v: %v", a.Filename, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not download attachment %v: got status %v", a.Filename, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("could not read attachment %v: %v", a.Filename, err)
+	}
+
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("attachment %v is bigger than the maximum allowed of %v bytes", a.Filename, maxSize)
+	}
+
+	return data, nil
+}
+
+// isProgramAttachment tells if an attached file holds a Brainfuck program, by its extension
+func isProgramAttachment(a *dgo.MessageAttachment) bool {
+	ext := strings.ToLower(path.Ext(a.Filename))
+	for _, e := range programExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// extractProgramAttachment downloads the program attached to the request, if there is one,
+// adding it as the last positional argument and removing it from the attachments
+func extractProgramAttachment(req *Request) error {
+	var rest []*dgo.MessageAttachment
+	var program *dgo.MessageAttachment
+
+	for _, a := range req.Attachments {
+		if program == nil && isProgramAttachment(a) {
+			program = a
+			continue
+		}
+		rest = append(rest, a)
+	}
+
+	if program == nil {
+		return nil
+	}
+
+	data, err := fetchAttachmentLimited(program, maxProgramAttachmentSize)
+	if err != nil {
+		return err
+	}
+
+	req.Args = append(req.Args, string(data))
+	req.Attachments = rest
+	return nil
+}
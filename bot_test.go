@@ -0,0 +1,478 @@
+package main
+
+import (
+	bf "brainfuck-discord-bot/brainfuck"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	dgo "github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+const testPrefix = "!bf"
+
+func TestMain(m *testing.M) {
+	log.SetOutput(ioutil.Discard)
+
+	bot_prefix = testPrefix
+
+	// Tests send many commands as the same user, see TestRateLimit for the limits
+	userLimiter = newRateLimiter(1_000_000, 60)
+	guildLimiter = newRateLimiter(1_000_000, 60)
+
+	dir, err := ioutil.TempDir("", "bf-bot-test")
+	if err != nil {
+		panic(err)
+	}
+	database, err := openDatabase(filepath.Join(dir, "bot.db"))
+	if err != nil {
+		panic(err)
+	}
+	snippets = &snippetStore{db: database}
+	shares = &shareStore{db: database}
+	challenges = &challengeStore{db: database}
+
+	code := m.Run()
+	database.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// attachmentServer serves the contents of test attachments, by path
+func attachmentServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func testMessage(content string, attachments ...*dgo.MessageAttachment) *dgo.Message {
+	return &dgo.Message{
+		ID:          "command",
+		ChannelID:   "channel",
+		GuildID:     "guild",
+		Content:     content,
+		Author:      &dgo.User{ID: "author", Username: "tester"},
+		Attachments: attachments,
+	}
+}
+
+// checkEmbed checks the title of the embed and the values of some of its fields
+func checkEmbed(t *testing.T, embed *dgo.MessageEmbed, wantTitle string, wantFields map[string]string) {
+	t.Helper()
+
+	if embed.Title != wantTitle {
+		t.Errorf("reply title = %q, want %q (description: %v)", embed.Title, wantTitle, embed.Description)
+	}
+
+	for name, want := range wantFields {
+		found := false
+		for _, f := range embed.Fields {
+			if f.Name == name {
+				found = true
+				if f.Value != want {
+					t.Errorf("reply field %q = %q, want %q", name, f.Value, want)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("reply has no field %q", name)
+		}
+	}
+}
+
+func TestHandleMessage(t *testing.T) {
+	srv := attachmentServer(t, map[string]string{"/input.txt": "abc\x00", "/echo.bf": ",[.,]"})
+	inputFile := &dgo.MessageAttachment{Filename: "input.txt", URL: srv.URL + "/input.txt", Size: 4}
+	programFile := &dgo.MessageAttachment{Filename: "echo.bf", URL: srv.URL + "/echo.bf", Size: 5}
+	missingFile := &dgo.MessageAttachment{Filename: "missing.txt", URL: srv.URL + "/missing.txt", Size: 4}
+
+	tests := []struct {
+		name        string
+		msg         *dgo.Message
+		wantNoReply bool
+		wantTitle   string
+		wantFields  map[string]string
+	}{
+		{name: "not a command", msg: testMessage("hello"), wantNoReply: true},
+		{name: "other prefix", msg: testMessage("!bfx help"), wantNoReply: true},
+		{name: "prefix only", msg: testMessage("!bf  "), wantTitle: "Brainfuck Bot Help"},
+		{name: "help", msg: testMessage("!bf help"), wantTitle: "Brainfuck Bot Help"},
+		{name: "command help", msg: testMessage("!bf help short"), wantTitle: "Help for shorten"},
+		{name: "help of unknown command", msg: testMessage("!bf help nope"), wantTitle: "Command **nope** does not exist"},
+		{name: "unknown command", msg: testMessage("!bf nope"), wantTitle: "Command **nope** does not exist"},
+		{name: "unterminated quote", msg: testMessage(`!bf exec "abc ,.`), wantTitle: "Invalid command"},
+		{
+			name:       "exec",
+			msg:        testMessage("!bf exec ++++++++[>++++++++<-]>+."),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Output": "A"},
+		},
+		{
+			name:       "exec with numbers",
+			msg:        testMessage("!bf exec 65,0x42 ,.,."),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Output": "AB"},
+		},
+		{
+			name:       "exec with string",
+			msg:        testMessage(`!bf exec "'hi\n'" ,.,.,.`),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Output": "hi\n<EOF>"},
+		},
+		{
+			name:       "exec with attached input",
+			msg:        testMessage("!bf exec ,[.,]", inputFile),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Output": "abc"},
+		},
+		{
+			name:       "exec code block",
+			msg:        testMessage("!bf exec 'hey' ```bf\n,.,.\n,.\n```"),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Output": "hey"},
+		},
+		{
+			name:       "exec attached program",
+			msg:        testMessage(`!bf exec '\x41\0'`, programFile),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Output": "A"},
+		},
+		{
+			name:       "exec attached program and input",
+			msg:        testMessage("!bf exec", programFile, inputFile),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Output": "abc"},
+		},
+		{
+			name:       "exec with output mode",
+			msg:        testMessage("!bf exec --out=hex -.+."),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Output": "ff 00"},
+		},
+		{
+			name:       "exec without output",
+			msg:        testMessage("!bf exec +"),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Output": "No output"},
+		},
+		{name: "exec without program", msg: testMessage("!bf exec"), wantTitle: "Invalid arguments"},
+		{name: "exec with unknown option", msg: testMessage("!bf exec --nope=1 +"), wantTitle: "Invalid option"},
+		{name: "exec with bad output mode", msg: testMessage("!bf exec --out=nope +"), wantTitle: "Invalid option"},
+		{name: "exec with bad limit", msg: testMessage("!bf exec --limit=0 +"), wantTitle: "Invalid option"},
+		{name: "exec with non numeric cells", msg: testMessage("!bf exec --cells=many +"), wantTitle: "Invalid option"},
+		{name: "exec with input argument and option", msg: testMessage("!bf exec --input=1 2 ,."), wantTitle: "Invalid arguments"},
+		{
+			name:       "exec with input option",
+			msg:        testMessage(`!bf exec --input='hi' ,.,.`),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Output": "hi"},
+		},
+		{
+			name:       "exec with dump",
+			msg:        testMessage("!bf exec --dump +++>++"),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Memory": "```\n0: 3\n1: 2\n```"},
+		},
+		{name: "exec over its instruction limit", msg: testMessage("!bf exec --limit=10 +[]"), wantTitle: "Execution error"},
+		{name: "exec over its cell limit", msg: testMessage("!bf exec --cells=2 +>+>+"), wantTitle: "Execution error"},
+		{
+			name:       "encode code points",
+			msg:        testMessage("!bf encode --format=codepoints é"),
+			wantTitle:  "",
+			wantFields: map[string]string{"Target output": "é"},
+		},
+		{
+			name:       "encode with an apostrophe",
+			msg:        testMessage("!bf encode I'm here"),
+			wantTitle:  "",
+			wantFields: map[string]string{"Target output": "I'm here"},
+		},
+		{
+			name:       "exec with an apostrophe in a comment",
+			msg:        testMessage("!bf exec ```bf\nprints A and doesn't read\n++++++++[>++++++++<-]>+.\n```"),
+			wantTitle:  "Execution successful",
+			wantFields: map[string]string{"Output": "A"},
+		},
+		{name: "encode with bad format", msg: testMessage("!bf encode --format=nope a"), wantTitle: "Invalid option"},
+		{name: "exec with bad input", msg: testMessage("!bf exec abc ,."), wantTitle: "Input parsing error"},
+		{name: "exec with missing attachment", msg: testMessage("!bf exec ,.", missingFile), wantTitle: "Input parsing error"},
+		{name: "exec with input and attachment", msg: testMessage("!bf exec 1 ,.", inputFile), wantTitle: "Invalid arguments"},
+		{name: "exec compilation error", msg: testMessage("!bf exec +]"), wantTitle: "Compilation Error"},
+		{name: "exec execution error", msg: testMessage("!bf exec ,"), wantTitle: "Execution error"},
+		{
+			name:       "shorten",
+			msg:        testMessage("!bf shorten ++++[>--<]"),
+			wantFields: map[string]string{"Short version": "+4[>-2<]"},
+		},
+		{
+			name:       "shorten alias",
+			msg:        testMessage("!bf short ..."),
+			wantFields: map[string]string{"Short version": ".3"},
+		},
+		{
+			name:       "shorten attached program",
+			msg:        testMessage("!bf shorten", programFile),
+			wantFields: map[string]string{"Short version": ",[.,]"},
+		},
+		{name: "shorten without program", msg: testMessage("!bf shorten"), wantTitle: "Invalid arguments"},
+		{
+			name:       "encode",
+			msg:        testMessage("!bf encode Hi there"),
+			wantFields: map[string]string{"Target output": "Hi there"},
+		},
+		{
+			name:       "encode attachment",
+			msg:        testMessage("!bf encode", inputFile),
+			wantFields: map[string]string{"Target output": "4 bytes from input.txt"},
+		},
+		{name: "encode without text", msg: testMessage("!bf encode"), wantTitle: "Invalid number of arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newFakeTransport()
+			handleMessage(transport, testPrefix, tt.msg)
+
+			sent := transport.Sent()
+			if tt.wantNoReply {
+				if len(sent) != 0 {
+					t.Fatalf("expected no reply, but got %v", len(sent))
+				}
+				return
+			}
+
+			if len(sent) != 1 {
+				t.Fatalf("expected 1 reply, but got %v", len(sent))
+			}
+			if sent[0].ChannelID != tt.msg.ChannelID {
+				t.Errorf("reply sent to channel %v, want %v", sent[0].ChannelID, tt.msg.ChannelID)
+			}
+
+			checkEmbed(t, sent[0].Embeds[0], tt.wantTitle, tt.wantFields)
+		})
+	}
+}
+
+func TestEncodeProgramOutputsTarget(t *testing.T) {
+	transport := newFakeTransport()
+	handleMessage(transport, testPrefix, testMessage("!bf encode olá mundo"))
+
+	var program string
+	for _, f := range transport.Sent()[0].Embeds[0].Fields {
+		if f.Name == "Brainfuck Program" {
+			program = f.Value
+		}
+	}
+
+	p, err := bf.Compile(program)
+	if err != nil {
+		t.Fatalf("encoded program does not compile: %v", err)
+	}
+	res, err := p.Execute(bf.Config{})
+	if err != nil {
+		t.Fatalf("encoded program failed: %v", err)
+	}
+	if got := string(res.Output); got != "olá mundo" {
+		t.Errorf("encoded program outputs %q, want %q", got, "olá mundo")
+	}
+}
+
+func TestHandleMessageEdit(t *testing.T) {
+	transport := newFakeTransport()
+	msg := testMessage("!bf exec +[")
+	msg.ID = "edited-command"
+	handleMessage(transport, testPrefix, msg)
+
+	edited := testMessage("!bf exec " + strings.Repeat("+", 33) + ".")
+	edited.ID = msg.ID
+	handleMessageEdit(transport, testPrefix, edited)
+
+	sent := transport.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected the reply to be edited, but got %v replies", len(sent))
+	}
+	checkEmbed(t, sent[0].Embeds[0], "Execution successful", map[string]string{"Output": "!"})
+
+	// Edits of messages the bot did not reply to are ignored
+	unknown := testMessage("!bf help")
+	unknown.ID = "unknown-command"
+	handleMessageEdit(transport, testPrefix, unknown)
+	if n := len(transport.Sent()); n != 1 {
+		t.Errorf("edit of an unknown message sent %v replies, want none", n-1)
+	}
+}
+
+func TestHandleMessageEditPaged(t *testing.T) {
+	transport := newFakeTransport()
+	// Outputs 2000 'a's, which take two pages
+	msg := testMessage("!bf exec " + repeatProgram(20, 100))
+	msg.ID = "edited-paged-command"
+	handleMessage(transport, testPrefix, msg)
+
+	reply := transport.Sent()[0]
+	defer unwatchReactions(reply.ID)
+
+	edited := testMessage("!bf exec " + strings.Repeat("+", 33) + ".")
+	edited.ID = msg.ID
+	handleMessageEdit(transport, testPrefix, edited)
+	checkEmbed(t, reply.Embeds[0], "Execution successful", map[string]string{"Output": "!"})
+
+	if got, want := transport.reactions[reply.ID], []string{deleteEmoji}; !reflect.DeepEqual(got, want) {
+		t.Errorf("edited reply reactions = %q, want %q", got, want)
+	}
+
+	// The pages of the previous output can not be shown anymore
+	handleReaction("bot", &dgo.MessageReaction{
+		UserID:    "author",
+		MessageID: reply.ID,
+		ChannelID: reply.ChannelID,
+		Emoji:     dgo.Emoji{Name: nextPageEmoji},
+	})
+	checkEmbed(t, reply.Embeds[0], "Execution successful", map[string]string{"Output": "!"})
+	if n := len(transport.Sent()); n != 1 {
+		t.Errorf("edit of a paged reply sent %v messages, want the reply to be edited", n)
+	}
+}
+
+func TestHandleMessageEditRemovingCommand(t *testing.T) {
+	transport := newFakeTransport()
+	msg := testMessage("!bf help")
+	msg.ID = "uncommanded-message"
+	handleMessage(transport, testPrefix, msg)
+
+	edited := testMessage("never mind")
+	edited.ID = msg.ID
+	handleMessageEdit(transport, testPrefix, edited)
+	if n := len(transport.Sent()); n != 0 {
+		t.Errorf("edit removing the command left %v replies, want none", n)
+	}
+	if _, ok := replies.Get(msg.ID); ok {
+		t.Errorf("edit removing the command kept the reply cached")
+	}
+}
+
+func TestHandleMessageDelete(t *testing.T) {
+	transport := newFakeTransport()
+	msg := testMessage("!bf help")
+	msg.ID = "deleted-command"
+	handleMessage(transport, testPrefix, msg)
+
+	handleMessageDelete(transport, &dgo.Message{ID: "other-message"})
+	if n := len(transport.Sent()); n != 1 {
+		t.Fatalf("deleting another message left %v replies, want 1", n)
+	}
+
+	handleMessageDelete(transport, &dgo.Message{ID: msg.ID, ChannelID: msg.ChannelID})
+	if n := len(transport.Sent()); n != 0 {
+		t.Errorf("deleting the command left %v replies, want none", n)
+	}
+}
+
+func TestDeleteReaction(t *testing.T) {
+	transport := newFakeTransport()
+	msg := testMessage("!bf shorten +-")
+	msg.ID = "command-with-delete-reaction"
+	handleMessage(transport, testPrefix, msg)
+
+	reply := transport.Sent()[0]
+	if got, want := transport.reactions[reply.ID], []string{deleteEmoji}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reply reactions = %q, want %q", got, want)
+	}
+
+	react := func(userID string) {
+		handleReaction("bot", &dgo.MessageReaction{
+			UserID:    userID,
+			MessageID: reply.ID,
+			ChannelID: reply.ChannelID,
+			Emoji:     dgo.Emoji{Name: "\U0001f5d1"},
+		})
+	}
+
+	react("someone else")
+	if n := len(transport.Sent()); n != 1 {
+		t.Fatalf("reaction of another user left %v replies, want 1", n)
+	}
+
+	react("author")
+	if n := len(transport.Sent()); n != 0 {
+		t.Errorf("reaction of the author left %v replies, want none", n)
+	}
+}
+
+// repeatProgram creates a program that outputs n*m 'a's
+func repeatProgram(n, m int) string {
+	return strings.Repeat("+", 97) + ">" + strings.Repeat("+", n) + "[>" + strings.Repeat("+", m) + "[<<.>>-]<-]"
+}
+
+func TestLongPayloadsAreAttached(t *testing.T) {
+	transport := newFakeTransport()
+	handleMessage(transport, testPrefix, testMessage("!bf exec "+repeatProgram(200, 100)))
+
+	reply := transport.Sent()[0]
+	checkEmbed(t, reply.Embeds[0], "Execution successful", map[string]string{
+		"Output (preview, see output.txt)": strings.Repeat("a", payloadPreviewSize-1) + "…",
+	})
+	if got := transport.Files(reply.ID)["output.txt"]; got != strings.Repeat("a", 20000) {
+		t.Errorf("output.txt has %v bytes, want 20000 'a's", len(got))
+	}
+
+	transport = newFakeTransport()
+	handleMessage(transport, testPrefix, testMessage("!bf encode "+strings.Repeat("long text ", 200)))
+
+	reply = transport.Sent()[0]
+	encoded, ok := transport.Files(reply.ID)["program.bf"]
+	if !ok {
+		t.Fatalf("encode of a long text did not attach program.bf")
+	}
+	p, err := bf.Compile(encoded)
+	if err != nil {
+		t.Fatalf("attached program does not compile: %v", err)
+	}
+	res, err := p.Execute(bf.Config{})
+	if err != nil {
+		t.Fatalf("attached program failed: %v", err)
+	}
+	if got, want := string(res.Output), strings.TrimSpace(strings.Repeat("long text ", 200)); got != want {
+		t.Errorf("attached program outputs %q, want %q", got, want)
+	}
+}
+
+func TestHandleInteraction(t *testing.T) {
+	transport := newFakeTransport()
+	i := &dgo.Interaction{
+		ID:        "interaction",
+		Type:      dgo.InteractionApplicationCommand,
+		ChannelID: "channel",
+		User:      &dgo.User{ID: "author"},
+		Data: dgo.ApplicationCommandInteractionData{
+			Name: "exec",
+			Options: []*dgo.ApplicationCommandInteractionDataOption{
+				{Name: "program", Type: dgo.ApplicationCommandOptionString, Value: ",.,."},
+				{Name: "input", Type: dgo.ApplicationCommandOptionString, Value: "'ok'"},
+			},
+		},
+	}
+
+	handleInteraction(transport, testPrefix, i)
+
+	reply, ok := transport.interactionReplies[i.ID]
+	if !ok {
+		t.Fatalf("the interaction got no reply")
+	}
+	checkEmbed(t, reply.Embeds[0], "Execution successful", map[string]string{"Output": "ok"})
+}
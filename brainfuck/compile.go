@@ -1,6 +1,9 @@
 package brainfuck
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Compile compiles a string representing a brainfuck program into a representation
 // that can be executed (see the (*Program).Execute() method)
@@ -81,3 +84,15 @@ func Compile(program string) (*Program, error) {
 
 	return &p, nil
 }
+
+// CodeSize returns the number of Brainfuck instructions in the program,
+// ignoring the other characters, like comments and whitespace
+func CodeSize(program string) int {
+	n := 0
+	for _, c := range program {
+		if strings.ContainsRune("><+-[].,", c) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,20 @@
+package brainfuck
+
+import "testing"
+
+func TestCodeSize(t *testing.T) {
+	tests := []struct {
+		program string
+		want    int
+	}{
+		{program: "", want: 0},
+		{program: "+-<>[].,", want: 8},
+		{program: "print A: ++++++++[>++++++++<-]>+.", want: 24},
+		{program: "no instructions here", want: 0},
+	}
+	for _, tt := range tests {
+		if got := CodeSize(tt.program); got != tt.want {
+			t.Errorf("CodeSize(%q) = %v, want %v", tt.program, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package brainfuck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Config holds the limits of an execution and how the memory and the input behave.
+// The zero value uses the defaults of every setting.
+type Config struct {
+	// MaxInstructions is the number of instructions after which the program is stopped.
+	// MaxExecInstructions is used if it is not positive.
+	MaxInstructions int
+	// MaxMemory is the number of memory cells the program can use.
+	// MaxMemory is used if it is not positive.
+	MaxMemory int
+	// CellWidth is the number of bits of the memory cells, 8 if it is zero
+	CellWidth CellWidth
+	// EOF is what input instructions do once the input has ended
+	EOF EOFMode
+}
+
+// withDefaults returns the config with the defaults in place of the unset settings
+func (c Config) withDefaults() Config {
+	if c.MaxInstructions <= 0 {
+		c.MaxInstructions = MaxExecInstructions
+	}
+	if c.MaxMemory <= 0 {
+		c.MaxMemory = MaxMemory
+	}
+	if c.CellWidth == 0 {
+		c.CellWidth = Cell8
+	}
+	return c
+}
+
+// CellWidth is the number of bits of the memory cells.
+// Cells wrap around, so decrementing a cell holding 0 sets all its bits.
+type CellWidth uint8
+
+const (
+	Cell8  CellWidth = 8
+	Cell16 CellWidth = 16
+	Cell32 CellWidth = 32
+)
+
+// ParseCellWidth returns the cell width with the given number of bits: 8, 16 or 32
+func ParseCellWidth(bits string) (CellWidth, error) {
+	switch strings.TrimSpace(bits) {
+	case "8":
+		return Cell8, nil
+	case "16":
+		return Cell16, nil
+	case "32":
+		return Cell32, nil
+	}
+	return 0, fmt.Errorf("unknown cell width %q: valid widths are 8, 16 and 32 bits", bits)
+}
+
+// mask returns the bits a cell of the width can hold
+func (w CellWidth) mask() int {
+	return 1<<uint(w) - 1
+}
+
+// EOFMode is what an input instruction does when there are no more inputs
+type EOFMode uint8
+
+const (
+	// EOFError stops the program with an error
+	EOFError EOFMode = iota
+	// EOFZero sets the cell to 0
+	EOFZero
+	// EOFMinusOne sets the cell to -1, that is, all its bits
+	EOFMinusOne
+	// EOFUnchanged leaves the cell as it is
+	EOFUnchanged
+)
+
+var eofModeNames = map[EOFMode]string{
+	EOFError:     "error",
+	EOFZero:      "zero",
+	EOFMinusOne:  "minus-one",
+	EOFUnchanged: "unchanged",
+}
+
+// String returns the name of the EOF mode, as accepted by ParseEOFMode
+func (m EOFMode) String() string {
+	if name, ok := eofModeNames[m]; ok {
+		return name
+	}
+	return "EOFMode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// ParseEOFMode returns the EOF mode with the given name.
+// The accepted names are "error", "zero", "minus-one" and "unchanged".
+func ParseEOFMode(name string) (EOFMode, error) {
+	for mode, modeName := range eofModeNames {
+		if strings.EqualFold(name, modeName) {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown EOF mode %q: valid modes are error, zero, minus-one and unchanged", name)
+}
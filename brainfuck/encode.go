@@ -1,6 +1,24 @@
 package brainfuck
 
-import "strings"
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// EncodeMode selects how the characters of a string are mapped into output cell values
+type EncodeMode uint8
+
+const (
+	// ByteEncoding outputs the UTF-8 bytes of the string, one '.' instruction per byte.
+	// The generated program assumes 8-bit wrapping cells.
+	ByteEncoding EncodeMode = iota
+	// CodePointEncoding outputs every Unicode code point of the string with a single '.'
+	// instruction. The generated program needs cells wide enough to hold the largest
+	// code point, but does not rely on cells wrapping around.
+	CodePointEncoding
+)
 
 var G [256][256]string
 
@@ -87,12 +105,31 @@ func init() {
 	}
 }
 
-// Encode creates a Brainfuck program that outputs the given string
-func Encode(s string) string {
+// Encode creates a Brainfuck program that outputs the given string, using the given
+// encoding mode.
+// An error is returned if the mode is unknown or if the string is not valid UTF-8 and
+// code point encoding was requested.
+func Encode(s string, mode EncodeMode) (string, error) {
+	switch mode {
+	case ByteEncoding:
+		return EncodeBytes([]byte(s)), nil
+	case CodePointEncoding:
+		if !utf8.ValidString(s) {
+			return "", fmt.Errorf("the text is not valid UTF-8, so it can't be encoded as code points")
+		}
+		return encodeCodePoints(s), nil
+	default:
+		return "", fmt.Errorf("unknown encode mode %v", mode)
+	}
+}
+
+// EncodeBytes creates a Brainfuck program that outputs the given bytes.
+// The program assumes 8-bit wrapping cells.
+func EncodeBytes(data []byte) string {
 	var res strings.Builder
-	lastc := rune(0)
+	lastc := byte(0)
 
-	for _, c := range s {
+	for _, c := range data {
 		a := G[lastc][c]
 		b := G[0][c]
 		if len(a) <= len(b) {
@@ -105,3 +142,42 @@ func Encode(s string) string {
 	}
 	return res.String()
 }
+
+// encodeCodePoints creates a Brainfuck program that outputs each code point of s
+// with a single '.' instruction. The value being output is always kept in the same cell,
+// and the cell to its right is used as a loop counter to reach distant values
+// with a multiplication.
+func encodeCodePoints(s string) string {
+	var res strings.Builder
+	last := 0
+
+	for _, c := range s {
+		delta := int(c) - last
+		op := "+"
+		if delta < 0 {
+			op = "-"
+			delta = -delta
+		}
+
+		// Find the factors a*b+r = delta that give the shortest program, where the
+		// multiplication is ">" + a*"+" + "[<" + b*op + ">-]<" + r*op
+		best, bestA, bestB := delta, 0, 0
+		for a := 2; a <= int(math.Sqrt(float64(delta)))+1; a++ {
+			b := delta / a
+			r := delta - a*b
+			if l := a + b + r + 7; l < best {
+				best, bestA, bestB = l, a, b
+			}
+		}
+
+		if bestA == 0 {
+			res.WriteString(strings.Repeat(op, delta))
+		} else {
+			res.WriteString(">" + strings.Repeat("+", bestA) + "[<" + strings.Repeat(op, bestB) + ">-]<")
+			res.WriteString(strings.Repeat(op, delta-bestA*bestB))
+		}
+		res.WriteString(".")
+		last = int(c)
+	}
+	return res.String()
+}
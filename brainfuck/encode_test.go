@@ -0,0 +1,146 @@
+package brainfuck
+
+import (
+	"strings"
+	"testing"
+)
+
+// interpret runs a program without inputs using cells that wrap at the given modulo
+// (0 meaning no wrapping) and returns the values output.
+func interpret(t *testing.T, program string, modulo int) []int {
+	t.Helper()
+
+	var out []int
+	mem := map[int]int{}
+	ap := 0
+
+	for pc := 0; pc < len(program); pc++ {
+		switch program[pc] {
+		case '>':
+			ap++
+		case '<':
+			ap--
+		case '+':
+			mem[ap]++
+		case '-':
+			mem[ap]--
+		case '.':
+			out = append(out, mem[ap])
+		case '[':
+			if mem[ap] == 0 {
+				for depth := 1; depth > 0; {
+					pc++
+					if program[pc] == '[' {
+						depth++
+					} else if program[pc] == ']' {
+						depth--
+					}
+				}
+			}
+		case ']':
+			if mem[ap] != 0 {
+				for depth := 1; depth > 0; {
+					pc--
+					if program[pc] == ']' {
+						depth++
+					} else if program[pc] == '[' {
+						depth--
+					}
+				}
+			}
+		}
+
+		if modulo != 0 {
+			mem[ap] = (mem[ap]%modulo + modulo) % modulo
+		}
+	}
+
+	return out
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		mode    EncodeMode
+		modulo  int
+		want    []int
+		wantErr bool
+	}{
+		{
+			name:   "ascii bytes",
+			text:   "Hi!",
+			mode:   ByteEncoding,
+			modulo: 256,
+			want:   []int{'H', 'i', '!'},
+		},
+		{
+			name:   "multi-byte utf-8",
+			text:   "é😀",
+			mode:   ByteEncoding,
+			modulo: 256,
+			want:   []int{0xc3, 0xa9, 0xf0, 0x9f, 0x98, 0x80},
+		},
+		{
+			name: "code points",
+			text: "aé😀b",
+			mode: CodePointEncoding,
+			want: []int{'a', 'é', '😀', 'b'},
+		},
+		{
+			name:    "invalid utf-8 code points",
+			text:    "a\xffb",
+			mode:    CodePointEncoding,
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode",
+			text:    "a",
+			mode:    EncodeMode(42),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := Encode(tt.text, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Encode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := interpret(t, program, tt.modulo)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Encode() program outputs %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Encode() program outputs %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeCodePointsLength(t *testing.T) {
+	// The multiplication is only used when it is shorter than a run of +s or -s, counting all
+	// of its instructions
+	const base = 400
+	prefix := len(encodeCodePoints(string(rune(base))))
+	for delta := -300; delta <= 300; delta++ {
+		program := encodeCodePoints(string(rune(base)) + string(rune(base+delta)))
+		run := delta
+		if run < 0 {
+			run = -run
+		}
+		got, plain := len(program)-prefix, run+1
+		if got > plain || got == plain && strings.Contains(program[prefix:], "[") {
+			t.Errorf("encodeCodePoints() for a delta of %v has %v instructions, but a run of +s or -s has %v", delta, got, plain)
+		}
+
+		if got := interpret(t, program, 0); len(got) != 2 || got[1] != base+delta {
+			t.Errorf("encodeCodePoints() for a delta of %v outputs %v, want %v", delta, got, base+delta)
+		}
+	}
+}
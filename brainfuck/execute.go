@@ -1,18 +1,28 @@
 package brainfuck
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"strings"
+	"io"
+	"io/ioutil"
+	"unicode/utf8"
 )
 
-// Max Memory cells a Brainfuck program is allowed to use
+// Default max Memory cells a Brainfuck program is allowed to use, see Config
 const MaxMemory = 30_000
 
-// Max number of instructions that will be executed before giving up.
+// Default max number of instructions that will be executed before giving up, see Config.
 // This is a protection against infinite loops or programs that will
 // run for very long otherwise.
 const MaxExecInstructions = 10_000_000
 
+// Default number of instructions between calls to the progress function of a running program
+const DefaultProgressInterval = 100_000
+
+// Number of instructions between checks for the cancellation of a running program
+const ctxCheckInterval = 4096
+
 // InstructionType represents a Brainfuck instruction
 type InstructionType uint8
 
@@ -49,13 +59,18 @@ type Instruction struct {
 type Program struct {
 	Source       string
 	Instructions []Instruction
-	Memory       map[int]int8
+	// Memory holds the values of the cells used by the last execution, by address.
+	// Values are unsigned, between 0 and the max value of the cell width.
+	Memory map[int]int
+
+	// Bits a memory cell can hold, set when the program runs
+	cellMask int
 }
 
 // GetMemValue gets the current value in memory at the given address.
 // If the address was never visited before, it will be initialized with 0 (zero).
-func (p *Program) GetMemValue(addr int) int8 {
-	var v int8
+func (p *Program) GetMemValue(addr int) int {
+	var v int
 	var ok bool
 
 	if v, ok = p.Memory[addr]; !ok {
@@ -69,22 +84,27 @@ func (p *Program) GetMemValue(addr int) int8 {
 // GetMemValue increments a value in memory at the given address.
 // If the address was never visited before, it will be initialized with 0 (zero)
 // and then the increment will be applied.
-func (p *Program) IncMemValue(addr int, inc int8) {
+func (p *Program) IncMemValue(addr int, inc int) {
 	oldVal := p.GetMemValue(addr)
-	p.Memory[addr] = oldVal + inc
+	p.SetMemValue(addr, oldVal+inc)
 }
 
 // DecMemValue decrements a value in memory at the given address.
 // If the address was never visited before, it will be initialized with 0 (zero)
 // and then the decrement will be applied.
-func (p *Program) DecMemValue(addr int, dec int8) {
+func (p *Program) DecMemValue(addr int, dec int) {
 	oldVal := p.GetMemValue(addr)
-	p.Memory[addr] = oldVal - dec
+	p.SetMemValue(addr, oldVal-dec)
 }
 
-// SetMemValue sets the memory value at the given address to the value specified.
-func (p *Program) SetMemValue(addr int, val int8) {
-	p.Memory[addr] = val
+// SetMemValue sets the memory value at the given address to the value specified,
+// wrapped around to the width of the cells.
+func (p *Program) SetMemValue(addr int, val int) {
+	mask := p.cellMask
+	if mask == 0 {
+		mask = Cell8.mask()
+	}
+	p.Memory[addr] = val & mask
 }
 
 // Execute executes the Brainfuck program returning *ExecutionResult that contains the output
@@ -92,23 +112,83 @@ func (p *Program) SetMemValue(addr int, val int8) {
 // Inputs can optionally be given to Execute and will be used to feed the program when an input
 // instruction happens (','). The number of input can be fewer than the number of input instructions,
 // in which case case the inputs will be fed in a cyclic manner.
-// Giving no inputs to a program that has
-func (p *Program) Execute(inputs ...int) (*ExecutionResult, error) {
-	p.Memory = make(map[int]int8)
+// Giving no inputs to a program that has input instructions results in an error, unless the
+// EOF mode of the config says otherwise.
+// The program runs within the limits and with the cell width of the config.
+// See Run for a version of Execute that streams the input and output.
+func (p *Program) Execute(config Config, inputs ...int) (*ExecutionResult, error) {
+	var out bytes.Buffer
 
-	var out strings.Builder
+	res, err := p.RunContext(context.Background(), RunOptions{
+		Input:  CyclicInput(inputs...),
+		Output: &out,
+		Config: config,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res.Output = out.Bytes()
+	return res, nil
+}
+
+// Run executes the Brainfuck program, reading the values for input instructions (',') from in
+// and writing every byte produced by an output instruction ('.') to out as soon as it happens.
+// A nil in behaves as an input that is always at its end.
+// The program fails if it reaches an input instruction after the input has ended.
+// The Output of the returned *ExecutionResult is left empty, since the output was already
+// written to out.
+func (p *Program) Run(in InputProvider, out io.Writer) (*ExecutionResult, error) {
+	return p.RunContext(context.Background(), RunOptions{Input: in, Output: out})
+}
+
+// RunContext executes the Brainfuck program like Run, with the input, output, config and
+// progress reporting given in opts.
+// The execution stops when ctx is done, in which case the error of the context is returned.
+func (p *Program) RunContext(ctx context.Context, opts RunOptions) (*ExecutionResult, error) {
+	config := opts.Config.withDefaults()
+	p.Memory = make(map[int]int)
+	p.cellMask = config.CellWidth.mask()
+
+	in, out := opts.Input, opts.Output
+	if in == nil {
+		in = CyclicInput()
+	}
+	if out == nil {
+		out = ioutil.Discard
+	}
+
+	progressInterval := opts.ProgressInterval
+	if progressInterval <= 0 {
+		progressInterval = DefaultProgressInterval
+	}
+
+	maxInstructions := config.MaxInstructions
+	maxMemory := config.MaxMemory
 
 	programSize := len(p.Instructions)
-	nInputs, currInput := len(inputs), 0
 	insExec := 0
 	currentMemSize := 0
 	pc := 0
 	ap := 0
+	outBuf := make([]byte, utf8.UTFMax)
 
 	for pc < programSize &&
-		insExec <= MaxExecInstructions &&
-		currentMemSize <= MaxMemory {
+		insExec < maxInstructions &&
+		currentMemSize <= maxMemory {
 		i := p.Instructions[pc]
+		insExec++
+
+		if insExec%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		if opts.Progress != nil && insExec%progressInterval == 0 {
+			opts.Progress(Progress{InstructionsExecuted: insExec, MemoryCellsUsed: len(p.Memory)})
+		}
+
 		switch i.InstructionType {
 		case Nop:
 		case IncrementDataPointer:
@@ -116,17 +196,32 @@ func (p *Program) Execute(inputs ...int) (*ExecutionResult, error) {
 		case DecrementDataPointer:
 			ap -= i.Value
 		case IncrementData:
-			p.IncMemValue(ap, int8(i.Value))
+			p.IncMemValue(ap, i.Value)
 		case DecrementData:
-			p.DecMemValue(ap, int8(i.Value))
+			p.DecMemValue(ap, i.Value)
 		case Output:
-			out.WriteRune(rune(p.GetMemValue(ap)))
+			if _, err := out.Write(outputBytes(outBuf, p.GetMemValue(ap), config.CellWidth)); err != nil {
+				return nil, fmt.Errorf("could not write the output of the instruction at position %v: %v", pc, err)
+			}
 		case Input:
-			if nInputs == 0 {
-				return nil, fmt.Errorf("there is an input instruction at position %v, but no inputs were given: please provide at least 1 input to this program", pc)
+			v, err := in.NextInput()
+			if err == io.EOF {
+				switch config.EOF {
+				case EOFZero:
+					p.SetMemValue(ap, 0)
+				case EOFMinusOne:
+					p.SetMemValue(ap, -1)
+				case EOFUnchanged:
+					// The cell keeps its value
+				default:
+					return nil, fmt.Errorf("there is an input instruction at position %v, but there are no more inputs: please provide more inputs to this program", pc)
+				}
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("could not read the input for the instruction at position %v: %v", pc, err)
 			}
-			p.SetMemValue(ap, int8(inputs[currInput%nInputs]))
-			currInput++
+			p.SetMemValue(ap, v)
 		case JmpForwardIfEqZero:
 			if p.GetMemValue(ap) == 0 {
 				pc = i.Value
@@ -139,35 +234,66 @@ func (p *Program) Execute(inputs ...int) (*ExecutionResult, error) {
 			}
 		case End:
 			return &ExecutionResult{
-				Output:               out.String(),
-				InstructionsExecuted: insExec + 1,
+				InstructionsExecuted: insExec,
 				MemoryCellsUsed:      len(p.Memory),
 			}, nil
 		default:
 		}
 		pc++
-		insExec++
 		currentMemSize = len(p.Memory)
 	}
 
-	if insExec > MaxExecInstructions {
-		return nil, fmt.Errorf("the program reached the maximum number of instructions allowed (%v) and so it was stopped", MaxExecInstructions)
+	if currentMemSize > maxMemory {
+		return nil, fmt.Errorf("the program reached the maximum number of Memory cells allowed (%v)", maxMemory)
 	}
 
-	if currentMemSize > MaxMemory {
-		return nil, fmt.Errorf("the program reached the maximum number of Memory cells allowed (%v)", MaxMemory)
+	// The program can run exactly maxInstructions instructions, so it only fails if it has more
+	if pc < programSize {
+		return nil, fmt.Errorf("the program reached the maximum number of instructions allowed (%v) and so it was stopped", maxInstructions)
 	}
 
 	return &ExecutionResult{
-		Output:               out.String(),
 		InstructionsExecuted: insExec,
 		MemoryCellsUsed:      len(p.Memory),
 	}, nil
 }
 
-// ExecutionResult contains information about a successful execution of a Brainfuck program
+// RunOptions holds the input, output and progress reporting of a program execution
+type RunOptions struct {
+	// Input feeds the input instructions (','). A nil Input is always at its end.
+	Input InputProvider
+	// Output receives the bytes of the output instructions ('.'). A nil Output discards them.
+	Output io.Writer
+	// Progress is called every ProgressInterval instructions while the program runs, if not nil
+	Progress func(Progress)
+	// ProgressInterval is the number of instructions between calls to Progress.
+	// DefaultProgressInterval is used if it is not positive.
+	ProgressInterval int
+	// Config holds the limits of the execution and the behavior of the memory and input
+	Config
+}
+
+// outputBytes returns the bytes written by an output instruction for the value of a cell.
+// 8 bit cells write their value as a byte, while wider cells write the Unicode code point
+// they hold encoded as UTF-8.
+func outputBytes(buf []byte, v int, width CellWidth) []byte {
+	if width == Cell8 {
+		buf[0] = byte(v)
+		return buf[:1]
+	}
+	return buf[:utf8.EncodeRune(buf, rune(v))]
+}
+
+// Progress is a snapshot of the stats of a running program
+type Progress struct {
+	InstructionsExecuted int
+	MemoryCellsUsed      int
+}
+
+// ExecutionResult contains information about a successful execution of a Brainfuck program.
+// Output holds the raw bytes written by the program, see DecodeOutput to turn them into text.
 type ExecutionResult struct {
-	Output               string `json:"output"`
+	Output               []byte `json:"output"`
 	InstructionsExecuted int    `json:"instructions_executed"`
 	MemoryCellsUsed      int    `json:"memory_cells_used"`
 }
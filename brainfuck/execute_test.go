@@ -0,0 +1,213 @@
+package brainfuck
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name    string
+		program string
+		inputs  []int
+		config  Config
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:    "hello",
+			program: "++++++++[>+++++++++<-]>.+++++++++++++++++++++++++++++++++.",
+			want:    []byte("Hi"),
+		},
+		{
+			name:    "negative cell is output as a byte",
+			program: "-.",
+			want:    []byte{0xff},
+		},
+		{
+			name:    "cyclic inputs",
+			program: ",.,.,.",
+			inputs:  []int{65, 66},
+			want:    []byte("ABA"),
+		},
+		{
+			name:    "missing inputs",
+			program: ",.",
+			wantErr: true,
+		},
+		{
+			name:    "missing inputs read as zero",
+			program: "+,.",
+			config:  Config{EOF: EOFZero},
+			want:    []byte{0},
+		},
+		{
+			name:    "missing inputs read as minus one",
+			program: ",.",
+			config:  Config{EOF: EOFMinusOne},
+			want:    []byte{0xff},
+		},
+		{
+			name:    "missing inputs leave the cell unchanged",
+			program: "+++,.",
+			config:  Config{EOF: EOFUnchanged},
+			want:    []byte{3},
+		},
+		{
+			name:    "16 bit cells wrap around at 65536",
+			program: "-[-<+>]<[>+<-----]>.",
+			config:  Config{CellWidth: Cell16},
+			want:    []byte("\u3333"),
+		},
+		{
+			name:    "wide cells output code points",
+			program: ",.",
+			inputs:  []int{0x1f600},
+			config:  Config{CellWidth: Cell32},
+			want:    []byte("\U0001f600"),
+		},
+		{
+			name:    "instruction limit",
+			program: "+++++[-]",
+			config:  Config{MaxInstructions: 5},
+			wantErr: true,
+		},
+		{
+			name:    "exactly the instruction limit",
+			program: "+++++.",
+			config:  Config{MaxInstructions: 6},
+			want:    []byte{5},
+		},
+		{
+			name:    "one instruction over the limit",
+			program: "++++++.",
+			config:  Config{MaxInstructions: 6},
+			wantErr: true,
+		},
+		{
+			name:    "infinite loop",
+			program: "+[]",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Compile(tt.program)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			got, err := p.Execute(tt.config, tt.inputs...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !bytes.Equal(got.Output, tt.want) {
+				t.Errorf("Execute() output = %v, want %v", got.Output, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	p, err := Compile(",[.,]")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	res, err := p.Run(ReaderInput(bytes.NewReader([]byte("streamed\x00"))), &out)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if got := out.String(); got != "streamed" {
+		t.Errorf("Run() wrote %q, want %q", got, "streamed")
+	}
+	if res.Output != nil {
+		t.Errorf("Run() result output = %v, want nil", res.Output)
+	}
+
+	if _, err := p.Run(ReaderInput(bytes.NewReader([]byte("no terminator"))), &out); err == nil {
+		t.Errorf("Run() expected an error when the input ends")
+	}
+}
+
+func TestSliceInput(t *testing.T) {
+	p, err := Compile(",[.,]")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	_, err = p.RunContext(context.Background(), RunOptions{
+		Input:  SliceInput(300, 66),
+		Output: &out,
+		Config: Config{CellWidth: Cell16, EOF: EOFZero},
+	})
+	if err != nil {
+		t.Fatalf("RunContext() error = %v", err)
+	}
+	if got, want := out.String(), "\u012cB"; got != want {
+		t.Errorf("RunContext() wrote %q, want %q", got, want)
+	}
+
+	if _, err := p.Run(SliceInput(65), &out); err == nil {
+		t.Errorf("Run() expected an error when the input ends")
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	p, err := Compile("+[]")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var progress []Progress
+
+	_, err = p.RunContext(ctx, RunOptions{
+		ProgressInterval: 1000,
+		Progress: func(pr Progress) {
+			progress = append(progress, pr)
+			if len(progress) == 3 {
+				cancel()
+			}
+		},
+	})
+	if err != context.Canceled {
+		t.Fatalf("RunContext() error = %v, want %v", err, context.Canceled)
+	}
+
+	if progress[2].InstructionsExecuted != 3000 {
+		t.Errorf("RunContext() progress = %+v, want 3000 instructions in the third report", progress[2])
+	}
+}
+
+func TestInstructionLimit(t *testing.T) {
+	p, err := Compile("+++++.")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	// The instruction after the limit does not run, so it outputs nothing
+	var out bytes.Buffer
+	if _, err := p.RunContext(context.Background(), RunOptions{Output: &out, Config: Config{MaxInstructions: 5}}); err == nil {
+		t.Errorf("RunContext() expected an error over the instruction limit")
+	}
+	if out.Len() != 0 {
+		t.Errorf("RunContext() wrote %q after the instruction limit", out.String())
+	}
+
+	res, err := p.RunContext(context.Background(), RunOptions{Output: &out, Config: Config{MaxInstructions: 6}})
+	if err != nil {
+		t.Fatalf("RunContext() error = %v at the instruction limit", err)
+	}
+	if res.InstructionsExecuted != 6 {
+		t.Errorf("RunContext() executed %v instructions, want 6", res.InstructionsExecuted)
+	}
+}
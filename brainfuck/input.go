@@ -0,0 +1,68 @@
+package brainfuck
+
+import (
+	"bufio"
+	"io"
+)
+
+// InputProvider provides the values read by the input instructions (',') of a program
+type InputProvider interface {
+	// NextInput returns the next input value, or io.EOF if there are no more inputs
+	NextInput() (int, error)
+}
+
+// InputProviderFunc is an adapter to allow the use of ordinary functions as input providers
+type InputProviderFunc func() (int, error)
+
+// NextInput calls f()
+func (f InputProviderFunc) NextInput() (int, error) {
+	return f()
+}
+
+// ReaderInput returns an InputProvider that feeds the program with the bytes read from r,
+// one byte per input instruction
+func ReaderInput(r io.Reader) InputProvider {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return InputProviderFunc(func() (int, error) {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		return int(b), nil
+	})
+}
+
+// CyclicInput returns an InputProvider that feeds the program with the given values,
+// starting over from the first when all the values are used.
+// With no values, the input is always at its end.
+func CyclicInput(values ...int) InputProvider {
+	curr := 0
+
+	return InputProviderFunc(func() (int, error) {
+		if len(values) == 0 {
+			return 0, io.EOF
+		}
+		v := values[curr%len(values)]
+		curr++
+		return v, nil
+	})
+}
+
+// SliceInput returns an InputProvider that feeds the program with the given values once,
+// after which the input is at its end
+func SliceInput(values ...int) InputProvider {
+	curr := 0
+
+	return InputProviderFunc(func() (int, error) {
+		if curr >= len(values) {
+			return 0, io.EOF
+		}
+		v := values[curr]
+		curr++
+		return v, nil
+	})
+}
@@ -0,0 +1,83 @@
+package brainfuck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// OutputMode represents how the bytes output by a program are turned into text
+type OutputMode uint8
+
+const (
+	// UTF8Output decodes the output as UTF-8 text. Invalid sequences are replaced
+	// by the Unicode replacement character.
+	UTF8Output OutputMode = iota
+	// Latin1Output decodes each output byte as a Latin-1 (ISO 8859-1) character
+	Latin1Output
+	// DecimalOutput shows each output byte as a decimal number
+	DecimalOutput
+	// HexOutput shows each output byte as a two digit hexadecimal number
+	HexOutput
+)
+
+var outputModeNames = map[OutputMode]string{
+	UTF8Output:    "utf8",
+	Latin1Output:  "latin1",
+	DecimalOutput: "dec",
+	HexOutput:     "hex",
+}
+
+// String returns the name of the output mode, as accepted by ParseOutputMode
+func (m OutputMode) String() string {
+	if name, ok := outputModeNames[m]; ok {
+		return name
+	}
+	return "OutputMode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// ParseOutputMode returns the output mode with the given name.
+// The accepted names are "utf8", "latin1", "dec" and "hex".
+func ParseOutputMode(name string) (OutputMode, error) {
+	for mode, modeName := range outputModeNames {
+		if strings.EqualFold(name, modeName) {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown output mode %q: valid modes are utf8, latin1, dec and hex", name)
+}
+
+// DecodeOutput turns the bytes output by a program into text according to the given mode
+func DecodeOutput(output []byte, mode OutputMode) string {
+	var res strings.Builder
+
+	switch mode {
+	case Latin1Output:
+		for _, b := range output {
+			res.WriteRune(rune(b))
+		}
+	case DecimalOutput:
+		for i, b := range output {
+			if i > 0 {
+				res.WriteByte(' ')
+			}
+			res.WriteString(strconv.Itoa(int(b)))
+		}
+	case HexOutput:
+		for i, b := range output {
+			if i > 0 {
+				res.WriteByte(' ')
+			}
+			fmt.Fprintf(&res, "%02x", b)
+		}
+	default:
+		for len(output) > 0 {
+			r, size := utf8.DecodeRune(output)
+			res.WriteRune(r)
+			output = output[size:]
+		}
+	}
+
+	return res.String()
+}
@@ -0,0 +1,39 @@
+package brainfuck
+
+import "testing"
+
+func TestDecodeOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output []byte
+		mode   OutputMode
+		want   string
+	}{
+		{name: "utf8", output: []byte("olá 😀"), mode: UTF8Output, want: "olá 😀"},
+		{name: "utf8 invalid", output: []byte{'a', 0xff, 'b'}, mode: UTF8Output, want: "a�b"},
+		{name: "latin1", output: []byte{'a', 0xe9}, mode: Latin1Output, want: "aé"},
+		{name: "decimal", output: []byte{0, 65, 255}, mode: DecimalOutput, want: "0 65 255"},
+		{name: "hex", output: []byte{0, 65, 255}, mode: HexOutput, want: "00 41 ff"},
+		{name: "empty", output: nil, mode: HexOutput, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DecodeOutput(tt.output, tt.mode); got != tt.want {
+				t.Errorf("DecodeOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOutputMode(t *testing.T) {
+	for mode, name := range outputModeNames {
+		got, err := ParseOutputMode(name)
+		if err != nil || got != mode {
+			t.Errorf("ParseOutputMode(%q) = %v, %v, want %v", name, got, err, mode)
+		}
+	}
+
+	if _, err := ParseOutputMode("ebcdic"); err == nil {
+		t.Errorf("ParseOutputMode(%q) expected an error", "ebcdic")
+	}
+}
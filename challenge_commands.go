@@ -0,0 +1,471 @@
+package main
+
+import (
+	bf "brainfuck-discord-bot/brainfuck"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+// Number of results shown in the leaderboard of a challenge
+const leaderboardSize = 10
+
+var challengeCmd = &Command{
+	Name:        "challenge",
+	Aliases:     []string{"challenges"},
+	Description: "Lists, shows and manages the golf challenges of the server",
+	Details: "The actions are:\n" +
+		"`list` - lists the challenges\n" +
+		"`show <name>` - shows a challenge and its leaderboard\n" +
+		"`create <name> [description...]` - creates a challenge\n" +
+		"`add-case <name> <input> <output>` - adds a test case, with the input and the expected output written like the input of exec, e.g. `add-case hello '' 'Hello'`\n" +
+		"`delete <name>` - deletes a challenge and its results\n" +
+		"Creating and changing challenges requires the Manage Server permission. Programs are submitted with `submit`.",
+	Args: argSchema{Min: 1, Max: -1},
+	Params: []Param{
+		{Name: "action", Description: "list, show, create, add-case or delete", Required: true},
+		{Name: "name", Description: "The name of the challenge"},
+		{Name: "arguments", Description: "The description of a new challenge, or the input and output of a test case", Variadic: true},
+	},
+	Handler: challengeCommand,
+}
+
+var submitCmd = &Command{
+	Name:        "submit",
+	Description: "Submits a program to a golf challenge",
+	Details: "The program runs with the input of every test case of the challenge, and is accepted if it produces the expected outputs. " +
+		"Reading past the end of an input behaves like the `eof` setting of the server says, and all the test cases must run within its time limit. " +
+		"Programs are ranked by their number of instructions, not counting comments and whitespace, and then by the instructions they execute in all the test cases. " +
+		"Your best program is kept in the leaderboard.\n" +
+		"The program can be given in a code block or as an attached `.bf` file.",
+	Args: argSchema{Min: 2, Max: 2},
+	Params: []Param{
+		{Name: "challenge", Description: "The name of the challenge", Required: true},
+		{Name: "program", Description: "The Brainfuck program to submit, unless attached as a .bf file", Required: true},
+	},
+	Attachment:        ".bf file with the program",
+	ProgramAttachment: true,
+	RunsPrograms:      true,
+	Handler:           submitCommand,
+}
+
+// challengeActions run the actions of the challenge command, by name, with the arguments
+// following the action. The number of arguments is checked by challengeCommand.
+var challengeActions = map[string]struct {
+	Args  argSchema
+	Admin bool
+	Run   func(req *Request, args []string) (*dgo.MessageEmbed, error)
+}{
+	"list":     {Args: argSchema{Min: 0, Max: 0}, Run: listChallenges},
+	"show":     {Args: argSchema{Min: 1, Max: 1}, Run: showChallenge},
+	"create":   {Args: argSchema{Min: 1, Max: -1}, Admin: true, Run: createChallenge},
+	"add-case": {Args: argSchema{Min: 3, Max: 3}, Admin: true, Run: addChallengeCase},
+	"delete":   {Args: argSchema{Min: 1, Max: 1}, Admin: true, Run: deleteChallenge},
+}
+
+func challengeCommand(req *Request) (*dgo.MessageEmbed, error) {
+	if embed, err := requireGuild(req, "The challenges"); embed != nil {
+		return embed, err
+	}
+
+	name := strings.ToLower(req.Args[0])
+	args := req.Args[1:]
+
+	action, ok := challengeActions[name]
+	var err error
+	switch {
+	case !ok:
+		err = fmt.Errorf("unknown action `%v`", req.Args[0])
+	case len(args) < action.Args.Min || (action.Args.Max >= 0 && len(args) > action.Args.Max):
+		err = fmt.Errorf("wrong number of arguments to %v: got %v argument(s)", name, len(args))
+	}
+	if err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Invalid arguments",
+			Description: err.Error() + fmt.Sprintf("\nType `%v help challenge` for more information", req.Prefix),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	if action.Admin {
+		if embed, err := requireAdmin(req, "Managing the challenges of the server"); embed != nil {
+			return embed, err
+		}
+	}
+
+	return action.Run(req, args)
+}
+
+// findChallenge gets the challenge of the guild of the request with the given name, or returns
+// the embed to reply with if there is none
+func findChallenge(req *Request, name string) (Challenge, *dgo.MessageEmbed, error) {
+	c, err := challenges.Get(req.GuildID, strings.ToLower(name))
+	if err == errChallengeNotFound {
+		err = fmt.Errorf("there is no challenge named `%v`", name)
+		return c, &dgo.MessageEmbed{
+			Title:       "Unknown challenge",
+			Description: err.Error() + fmt.Sprintf("\nType `%v challenge list` to see the challenges", req.Prefix),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+	if err != nil {
+		return c, databaseErrorEmbed(err), err
+	}
+	return c, nil, nil
+}
+
+func listChallenges(req *Request, args []string) (*dgo.MessageEmbed, error) {
+	list, err := challenges.List(req.GuildID)
+	if err != nil {
+		return databaseErrorEmbed(err), err
+	}
+
+	if len(list) == 0 {
+		return &dgo.MessageEmbed{
+			Title:       "Challenges",
+			Description: fmt.Sprintf("There are no challenges yet. Type `%v help challenge` to learn how to create one.", req.Prefix),
+			Color:       InfoColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, nil
+	}
+
+	var lines strings.Builder
+	for _, c := range list {
+		fmt.Fprintf(&lines, "`%v` - %v test case(s), %v solver(s)\n", c.Name, len(c.Cases), len(c.Results))
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Challenges",
+		Description: fmt.Sprintf("Type `%v challenge show <name>` to see a challenge.", req.Prefix),
+		Color:       InfoColor,
+		Fields: []*dgo.MessageEmbedField{
+			req.pagedField("Challenges of the server", lines.String()),
+		},
+		Type: dgo.EmbedTypeArticle,
+	}, nil
+}
+
+func showChallenge(req *Request, args []string) (*dgo.MessageEmbed, error) {
+	c, embed, err := findChallenge(req, args[0])
+	if embed != nil {
+		return embed, err
+	}
+
+	description := c.Description
+	if description == "" {
+		description = "No description."
+	}
+
+	leaderboard := "No solutions yet"
+	if results := c.Leaderboard(); len(results) > 0 {
+		var lines strings.Builder
+		for i, r := range results {
+			if i == leaderboardSize {
+				break
+			}
+			fmt.Fprintf(&lines, "%v. <@%v> - %v instructions, %v executed\n", i+1, r.UserID, r.Size, r.Instructions)
+		}
+		leaderboard = lines.String()
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Challenge " + c.Name,
+		Description: description,
+		Color:       InfoColor,
+		Fields: []*dgo.MessageEmbedField{
+			{Name: "Test cases", Value: strconv.Itoa(len(c.Cases)), Inline: true},
+			{Name: "Author", Value: fmt.Sprintf("<@%v>", c.AuthorID), Inline: true},
+			{Name: "Leaderboard", Value: leaderboard, Inline: false},
+			{Name: "Submit", Value: fmt.Sprintf("`%v submit %v <program>`", req.Prefix, c.Name), Inline: false},
+		},
+		Type: dgo.EmbedTypeArticle,
+	}, nil
+}
+
+func createChallenge(req *Request, args []string) (*dgo.MessageEmbed, error) {
+	name := strings.ToLower(args[0])
+	if err := validateName("challenge", name); err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Invalid arguments",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	err := challenges.Create(req.GuildID, Challenge{
+		Name:        name,
+		Description: strings.Join(args[1:], " "),
+		AuthorID:    req.Author.ID,
+		Created:     time.Now().UTC(),
+	})
+	if err == errChallengeExists {
+		err = fmt.Errorf("there is already a challenge named `%v`", name)
+		return &dgo.MessageEmbed{
+			Title:       "Could not create the challenge",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+	if err != nil {
+		return databaseErrorEmbed(err), err
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Challenge created",
+		Description: fmt.Sprintf("Add its test cases with `%v challenge add-case %v <input> <output>`.", req.Prefix, name),
+		Color:       SuccessColor,
+		Type:        dgo.EmbedTypeArticle,
+	}, nil
+}
+
+func addChallengeCase(req *Request, args []string) (*dgo.MessageEmbed, error) {
+	input, err := parseInput(args[1])
+	var output []byte
+	if err == nil {
+		output, err = parseExpectedOutput(args[2])
+	}
+	if err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Input parsing error",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	var n int
+	err = challenges.Update(req.GuildID, strings.ToLower(args[0]), func(c *Challenge) error {
+		if len(c.Cases) >= maxChallengeCases {
+			return errTooManyCases
+		}
+		c.Cases = append(c.Cases, TestCase{Input: input, Output: output})
+		// Results of the previous test cases may not pass the new one
+		c.Results = nil
+		n = len(c.Cases)
+		return nil
+	})
+	if err == errChallengeNotFound {
+		_, embed, err := findChallenge(req, args[0])
+		return embed, err
+	}
+	if err != nil && err != errTooManyCases {
+		return databaseErrorEmbed(err), err
+	}
+	if err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Could not add the test case",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Test case added",
+		Description: fmt.Sprintf("The challenge has %v test case(s). Its leaderboard was cleared, since the previous solutions may not pass the new test case.", n),
+		Color:       SuccessColor,
+		Type:        dgo.EmbedTypeArticle,
+	}, nil
+}
+
+func deleteChallenge(req *Request, args []string) (*dgo.MessageEmbed, error) {
+	err := challenges.Delete(req.GuildID, strings.ToLower(args[0]))
+	if err == errChallengeNotFound {
+		_, embed, err := findChallenge(req, args[0])
+		return embed, err
+	}
+	if err != nil {
+		return databaseErrorEmbed(err), err
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Challenge deleted",
+		Description: fmt.Sprintf("The challenge %v and its results were deleted.", strings.ToLower(args[0])),
+		Color:       SuccessColor,
+		Type:        dgo.EmbedTypeArticle,
+	}, nil
+}
+
+// parseExpectedOutput parses the expected output of a test case, written like the input of exec
+func parseExpectedOutput(text string) ([]byte, error) {
+	values, err := parseInput(text)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]byte, len(values))
+	for i, v := range values {
+		if v < 0 || v > 255 {
+			return nil, fmt.Errorf("the expected output is made of bytes, but it has the value %v", v)
+		}
+		output[i] = byte(v)
+	}
+	return output, nil
+}
+
+// formatValues shows the values of an input like they can be given to exec
+func formatValues(values []int) string {
+	if len(values) == 0 {
+		return "No input"
+	}
+
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return truncate(strings.Join(parts, ","), payloadPreviewSize)
+}
+
+func submitCommand(req *Request) (*dgo.MessageEmbed, error) {
+	if embed, err := requireGuild(req, "The challenges"); embed != nil {
+		return embed, err
+	}
+
+	c, embed, err := findChallenge(req, req.Args[0])
+	if embed != nil {
+		return embed, err
+	}
+
+	if len(c.Cases) == 0 {
+		err := fmt.Errorf("the challenge %v has no test cases yet", c.Name)
+		return &dgo.MessageEmbed{
+			Title:       "Challenge not ready",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	program := req.Args[1]
+	if len(program) > maxSnippetSize {
+		err := fmt.Errorf("the program has %v bytes, but submissions can have at most %v", len(program), maxSnippetSize)
+		return &dgo.MessageEmbed{
+			Title:       "Invalid arguments",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	p, err := bf.Compile(program)
+	if err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Compilation Error",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, fmt.Errorf("compilation error: %v", err)
+	}
+
+	// The timeout of the guild applies to the whole submission, not to each test case
+	config, timeout := guildExecConfig(req.GuildID)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	result := GolfResult{
+		UserID:    req.Author.ID,
+		Program:   program,
+		Size:      bf.CodeSize(program),
+		Submitted: time.Now().UTC(),
+	}
+
+	for i, tc := range c.Cases {
+		var output bytes.Buffer
+		res, err := p.RunContext(ctx, bf.RunOptions{
+			Input:  bf.SliceInput(tc.Input...),
+			Output: &output,
+			Config: config,
+		})
+		if err == context.DeadlineExceeded {
+			err = fmt.Errorf("the submission ran for longer than the time limit of %v", timeout)
+		}
+		if err != nil {
+			return &dgo.MessageEmbed{
+				Title:       "Execution error",
+				Description: fmt.Sprintf("The program failed in test case %v: %v", i+1, err),
+				Color:       ErrorColor,
+				Fields: []*dgo.MessageEmbedField{
+					{Name: "Input", Value: formatValues(tc.Input), Inline: false},
+				},
+				Type: dgo.EmbedTypeArticle,
+			}, fmt.Errorf("execution error in test case %v: %v", i+1, err)
+		}
+
+		if !bytes.Equal(output.Bytes(), tc.Output) {
+			err := fmt.Errorf("wrong output in test case %v", i+1)
+			return &dgo.MessageEmbed{
+				Title:       "Wrong answer",
+				Description: fmt.Sprintf("The program produced the wrong output in test case %v of %v.", i+1, len(c.Cases)),
+				Color:       ErrorColor,
+				Fields: []*dgo.MessageEmbedField{
+					{Name: "Input", Value: formatValues(tc.Input), Inline: false},
+					{Name: "Expected", Value: outputPreview(tc.Output), Inline: true},
+					{Name: "Got", Value: outputPreview(output.Bytes()), Inline: true},
+				},
+				Type: dgo.EmbedTypeArticle,
+			}, err
+		}
+
+		result.Instructions += res.InstructionsExecuted
+	}
+
+	var best GolfResult
+	var rank, solvers int
+	err = challenges.Update(req.GuildID, c.Name, func(c *Challenge) error {
+		best = result
+		if old, ok := c.Results[result.UserID]; ok && !result.better(old) {
+			best = old
+		}
+		if c.Results == nil {
+			c.Results = make(map[string]GolfResult)
+		}
+		c.Results[result.UserID] = best
+
+		leaderboard := c.Leaderboard()
+		for i, r := range leaderboard {
+			if r.UserID == result.UserID {
+				rank = i + 1
+			}
+		}
+		solvers = len(leaderboard)
+		return nil
+	})
+	if err != nil {
+		return databaseErrorEmbed(err), err
+	}
+
+	description := "New personal best!"
+	if best != result {
+		description = fmt.Sprintf("Your best solution is still the one with %v instructions, %v executed.", best.Size, best.Instructions)
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Challenge solved",
+		Description: description,
+		Color:       SuccessColor,
+		Fields: []*dgo.MessageEmbedField{
+			{Name: "Size", Value: fmt.Sprintf("%v instructions", result.Size), Inline: true},
+			{Name: "Executed", Value: fmt.Sprintf("%v instructions", result.Instructions), Inline: true},
+			{Name: "Rank", Value: fmt.Sprintf("%v of %v", rank, solvers), Inline: true},
+		},
+		Type: dgo.EmbedTypeArticle,
+	}, nil
+}
+
+// outputPreview shows the beginning of an output as a quoted UTF-8 string, so differences in
+// whitespace can be seen
+func outputPreview(output []byte) string {
+	if len(output) == 0 {
+		return "No output"
+	}
+	return truncate(strconv.Quote(bf.DecodeOutput(output, bf.UTF8Output)), payloadPreviewSize)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Max number of test cases of a challenge
+const maxChallengeCases = 20
+
+// Bucket of the database holding the challenges, in a nested bucket per guild
+var challengesBucket = []byte("challenges")
+
+var (
+	errChallengeNotFound = errors.New("challenge not found")
+	errChallengeExists   = errors.New("challenge already exists")
+	errTooManyCases      = fmt.Errorf("challenges can have at most %v test cases", maxChallengeCases)
+)
+
+// TestCase is an input of a challenge with the output programs must produce for it
+type TestCase struct {
+	Input  []int  `json:"input"`
+	Output []byte `json:"output"`
+}
+
+// GolfResult is the best accepted submission of a user to a challenge
+type GolfResult struct {
+	UserID  string `json:"user_id"`
+	Program string `json:"program"`
+	// Size is the number of instructions of the program, see bf.CodeSize
+	Size int `json:"size"`
+	// Instructions is the number of instructions executed in all the test cases
+	Instructions int       `json:"instructions"`
+	Submitted    time.Time `json:"submitted"`
+}
+
+// better tells if the result beats the other one: shorter programs win, then the programs that
+// execute fewer instructions, and then the earliest submission
+func (r GolfResult) better(other GolfResult) bool {
+	if r.Size != other.Size {
+		return r.Size < other.Size
+	}
+	if r.Instructions != other.Instructions {
+		return r.Instructions < other.Instructions
+	}
+	return r.Submitted.Before(other.Submitted)
+}
+
+// Challenge is a golf challenge of a guild: writing the shortest program producing the expected
+// output for the input of every test case
+type Challenge struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	AuthorID    string     `json:"author_id"`
+	Created     time.Time  `json:"created"`
+	Cases       []TestCase `json:"cases"`
+	// Best result of every user, by user ID
+	Results map[string]GolfResult `json:"results,omitempty"`
+}
+
+// Leaderboard returns the results of the challenge, from the best one
+func (c *Challenge) Leaderboard() []GolfResult {
+	res := make([]GolfResult, 0, len(c.Results))
+	for _, r := range c.Results {
+		res = append(res, r)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].better(res[j]) })
+	return res
+}
+
+// challengeStore keeps the challenges of the guilds in the database
+type challengeStore struct {
+	db *bolt.DB
+}
+
+// Create saves a new challenge in the guild, or returns errChallengeExists
+func (s *challengeStore) Create(guildID string, c Challenge) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		root, err := tx.CreateBucketIfNotExists(challengesBucket)
+		if err != nil {
+			return err
+		}
+		b, err := root.CreateBucketIfNotExists([]byte(guildID))
+		if err != nil {
+			return err
+		}
+		if b.Get([]byte(c.Name)) != nil {
+			return errChallengeExists
+		}
+		return putChallenge(b, c)
+	})
+}
+
+// Get returns the challenge of the guild with the given name, or errChallengeNotFound
+func (s *challengeStore) Get(guildID, name string) (Challenge, error) {
+	var c Challenge
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		c, err = getChallenge(guildBucket(tx, guildID), name)
+		return err
+	})
+	return c, err
+}
+
+// List returns the challenges of the guild, sorted by name
+func (s *challengeStore) List(guildID string) ([]Challenge, error) {
+	var res []Challenge
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := guildBucket(tx, guildID)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var c Challenge
+			if err := json.Unmarshal(v, &c); err != nil {
+				return err
+			}
+			res = append(res, c)
+			return nil
+		})
+	})
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, err
+}
+
+// Update changes the challenge of the guild with the given name with f and saves it.
+// Nothing changes if f returns an error, which is returned.
+func (s *challengeStore) Update(guildID, name string, f func(c *Challenge) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := guildBucket(tx, guildID)
+		c, err := getChallenge(b, name)
+		if err != nil {
+			return err
+		}
+		if err := f(&c); err != nil {
+			return err
+		}
+		return putChallenge(b, c)
+	})
+}
+
+// Delete deletes the challenge of the guild with the given name, or returns errChallengeNotFound
+func (s *challengeStore) Delete(guildID, name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := guildBucket(tx, guildID)
+		if b == nil || b.Get([]byte(name)) == nil {
+			return errChallengeNotFound
+		}
+		return b.Delete([]byte(name))
+	})
+}
+
+// guildBucket returns the bucket of the challenges of a guild, or nil if it has none
+func guildBucket(tx *bolt.Tx, guildID string) *bolt.Bucket {
+	root := tx.Bucket(challengesBucket)
+	if root == nil {
+		return nil
+	}
+	return root.Bucket([]byte(guildID))
+}
+
+func getChallenge(b *bolt.Bucket, name string) (Challenge, error) {
+	var c Challenge
+	if b == nil {
+		return c, errChallengeNotFound
+	}
+	data := b.Get([]byte(name))
+	if data == nil {
+		return c, errChallengeNotFound
+	}
+	err := json.Unmarshal(data, &c)
+	return c, err
+}
+
+func putChallenge(b *bolt.Bucket, c Challenge) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(c.Name), data)
+}
+
+// Golf challenges of the guilds, opened by main
+var challenges *challengeStore
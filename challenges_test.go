@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+func TestLeaderboard(t *testing.T) {
+	now := time.Now()
+	c := Challenge{Results: map[string]GolfResult{
+		"slow":  {UserID: "slow", Size: 10, Instructions: 500, Submitted: now},
+		"long":  {UserID: "long", Size: 12, Instructions: 5, Submitted: now},
+		"first": {UserID: "first", Size: 10, Instructions: 100, Submitted: now},
+		"late":  {UserID: "late", Size: 10, Instructions: 100, Submitted: now.Add(time.Minute)},
+	}}
+
+	var got []string
+	for _, r := range c.Leaderboard() {
+		got = append(got, r.UserID)
+	}
+	if want := []string{"first", "late", "slow", "long"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Leaderboard() = %v, want %v", got, want)
+	}
+}
+
+func TestChallengeCommands(t *testing.T) {
+	transport := newFakeTransport()
+	transport.permissions["admin"] = dgo.PermissionManageServer
+
+	send := func(content, authorID string) *dgo.MessageEmbed {
+		t.Helper()
+
+		msg := testMessage(content)
+		msg.GuildID = "golf-guild"
+		msg.Author.ID = authorID
+
+		handleMessage(transport, testPrefix, msg)
+		sent := transport.Sent()
+		return sent[len(sent)-1].Embeds[0]
+	}
+
+	checkEmbed(t, send("!bf challenge create echo Print the input", "author"), "Permission denied", nil)
+	checkEmbed(t, send("!bf challenge create echo Print the input", "admin"), "Challenge created", nil)
+	checkEmbed(t, send("!bf challenge create echo again", "admin"), "Could not create the challenge", nil)
+	checkEmbed(t, send("!bf submit echo ,[.,]", "author"), "Challenge not ready", nil)
+
+	checkEmbed(t, send(`!bf challenge add-case echo 'hi\0' 'hi'`, "admin"), "Test case added", nil)
+	checkEmbed(t, send(`!bf challenge add-case echo 'abc\0' 'abc'`, "admin"), "Test case added", nil)
+	checkEmbed(t, send(`!bf challenge add-case echo 0 256`, "admin"), "Input parsing error", nil)
+	checkEmbed(t, send(`!bf challenge add-case nope 0 0`, "admin"), "Unknown challenge", nil)
+
+	checkEmbed(t, send("!bf submit echo ,.", "author"), "Wrong answer", map[string]string{"Expected": `"hi"`, "Got": `"h"`})
+	checkEmbed(t, send("!bf submit echo +]", "author"), "Compilation Error", nil)
+	checkEmbed(t, send("!bf submit echo `read and print: ,[.,]`", "author"), "Challenge solved", map[string]string{
+		"Size": "5 instructions",
+		"Rank": "1 of 1",
+	})
+	embed := send("!bf submit echo `,[.,]+-`", "author")
+	checkEmbed(t, embed, "Challenge solved", map[string]string{"Size": "7 instructions"})
+	if embed.Description == "New personal best!" {
+		t.Errorf("a longer submission replaced the best one")
+	}
+	checkEmbed(t, send("!bf submit echo ,[.,]", "other"), "Challenge solved", map[string]string{"Rank": "2 of 2"})
+
+	checkEmbed(t, send("!bf challenge show echo", "other"), "Challenge echo", map[string]string{
+		"Test cases":  "2",
+		"Leaderboard": "1. <@author> - 5 instructions, 19 executed\n2. <@other> - 5 instructions, 19 executed\n",
+	})
+	checkEmbed(t, send("!bf challenge list", "other"), "Challenges", map[string]string{
+		"Challenges of the server": "`echo` - 2 test case(s), 2 solver(s)\n",
+	})
+
+	// The input of a test case ends like the inputs of the guild, so programs can read until it ends
+	checkEmbed(t, send(`!bf challenge create cat Print the input`, "admin"), "Challenge created", nil)
+	checkEmbed(t, send(`!bf challenge add-case cat 'meow' 'meow'`, "admin"), "Test case added", nil)
+	checkEmbed(t, send("!bf submit cat ,[.,]", "author"), "Execution error", nil)
+	checkEmbed(t, send("!bf config eof zero", "admin"), "Setting changed", nil)
+	checkEmbed(t, send("!bf submit cat ,[.,]", "author"), "Challenge solved", map[string]string{"Executed": "14 instructions"})
+
+	checkEmbed(t, send("!bf challenge delete echo", "admin"), "Challenge deleted", nil)
+	checkEmbed(t, send("!bf submit echo ,[.,]", "author"), "Unknown challenge", nil)
+}
@@ -0,0 +1,57 @@
+package main
+
+// Whether the bot answers to commands sent in direct messages, from the config, until the owners
+// of the bot change it with the channels command (see answersDirectMessages)
+var answerDirectMessages = true
+
+// IDs of the users who own the bot and can change the settings of direct messages, from the config
+var botOwners []string
+
+// The settings of direct messages are kept with the ones of the guilds, under an empty guild ID
+const directMessagesScope = ""
+
+// answersIn tells if the bot answers to the commands sent in a channel of a guild, according to
+// the channel lists of the guild, or in direct messages if guildID is empty.
+// Denied channels are never answered in, and if the guild allows some channels, only those are.
+func answersIn(guildID, channelID string) bool {
+	if guildID == "" {
+		return answersDirectMessages()
+	}
+
+	g := settings.Get(guildID)
+	if containsString(g.DeniedChannels, channelID) {
+		return false
+	}
+	return len(g.AllowedChannels) == 0 || containsString(g.AllowedChannels, channelID)
+}
+
+// answersDirectMessages tells if the bot answers to the commands sent in direct messages, as the
+// owners of the bot set with the channels command, or as the config says if they did not
+func answersDirectMessages() bool {
+	if answer := settings.Get(directMessagesScope).DirectMessages; answer != nil {
+		return *answer
+	}
+	return answerDirectMessages
+}
+
+// isOwner tells if the author of the request is an owner of the bot
+func (req *Request) isOwner() bool {
+	return containsString(botOwners, req.Author.ID)
+}
+
+// answersCommand tells if the bot answers to a command in a channel. The channels command is
+// answered everywhere, so the lists can be fixed from any channel if they lock the bot out.
+func answersCommand(name, guildID, channelID string) bool {
+	if c, ok := commands.Lookup(name); ok && c == channelsCmd {
+		return true
+	}
+	return answersIn(guildID, channelID)
+}
+
+// describeChannels lists the channels as mentions, or returns none if there are none
+func describeChannels(ids []string, none string) string {
+	if len(ids) == 0 {
+		return none
+	}
+	return mentionAll("<#%v>", ids)
+}
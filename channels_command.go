@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+var channelsCmd = &Command{
+	Name:        "channels",
+	Description: "Shows or changes the channels the bot answers in",
+	Details: "Without arguments, shows the allowed and denied channels of this server.\n" +
+		"`allow <channel>` makes the bot answer only in the allowed channels, `deny <channel>` makes it ignore the channel, " +
+		"`remove <channel>` takes the channel out of both lists and `reset` empties them. " +
+		"This command is answered in every channel, so the lists can always be changed back.\n" +
+		"Changing the channels requires the Manage Server permission.\n" +
+		"Direct messages are a scope of their own: `dms` shows if the bot answers in them, and the owners of the bot can change it with `dms on` or `dms off`.",
+	Args: argSchema{Min: 0, Max: 2},
+	Params: []Param{
+		{Name: "action", Description: "allow, deny, remove, reset or dms"},
+		{Name: "channel", Description: "The channel, as a mention or an ID, or on or off for dms"},
+	},
+	Handler: channelsCommand,
+}
+
+// channelsActions change the channel lists of a guild with a channel, by name
+var channelsActions = map[string]func(g *GuildSettings, id string){
+	"allow": func(g *GuildSettings, id string) {
+		g.DeniedChannels = removeString(g.DeniedChannels, id)
+		if !containsString(g.AllowedChannels, id) {
+			g.AllowedChannels = append(g.AllowedChannels, id)
+		}
+	},
+	"deny": func(g *GuildSettings, id string) {
+		g.AllowedChannels = removeString(g.AllowedChannels, id)
+		if !containsString(g.DeniedChannels, id) {
+			g.DeniedChannels = append(g.DeniedChannels, id)
+		}
+	},
+	"remove": func(g *GuildSettings, id string) {
+		g.AllowedChannels = removeString(g.AllowedChannels, id)
+		g.DeniedChannels = removeString(g.DeniedChannels, id)
+	},
+	"reset": func(g *GuildSettings, id string) {
+		g.AllowedChannels = nil
+		g.DeniedChannels = nil
+	},
+}
+
+func channelsCommand(req *Request) (*dgo.MessageEmbed, error) {
+	if len(req.Args) > 0 && strings.EqualFold(req.Args[0], "dms") {
+		return directMessagesCommand(req)
+	}
+
+	if embed, err := requireGuild(req, "The channel lists"); embed != nil {
+		return embed, err
+	}
+
+	if len(req.Args) == 0 {
+		return channelsEmbed("Channels", "", settings.Get(req.GuildID)), nil
+	}
+
+	name := strings.ToLower(req.Args[0])
+	action, ok := channelsActions[name]
+	var id string
+	var err error
+	switch {
+	case !ok:
+		err = fmt.Errorf("unknown action `%v`", req.Args[0])
+	case name != "reset" && len(req.Args) < 2:
+		err = fmt.Errorf("%v needs a channel", name)
+	case name == "reset" && len(req.Args) > 1:
+		err = fmt.Errorf("reset does not take a channel")
+	case name != "reset":
+		id, err = parseMention(req.Args[1], "<#%v>")
+	}
+	if err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Invalid arguments",
+			Description: err.Error() + fmt.Sprintf("\nType `%v help channels` for more information", req.Prefix),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	if embed, err := requireAdmin(req, "Changing the channels the bot answers in"); embed != nil {
+		return embed, err
+	}
+
+	err = settings.Update(req.GuildID, func(g *GuildSettings) error {
+		action(g, id)
+		return nil
+	})
+	if err != nil {
+		return settingsErrorEmbed(), err
+	}
+
+	embed := channelsEmbed("Channels changed", "The channels the bot answers in were changed.", settings.Get(req.GuildID))
+	embed.Color = SuccessColor
+	return embed, nil
+}
+
+// channelsEmbed shows the channel lists of a guild
+func channelsEmbed(title, description string, g GuildSettings) *dgo.MessageEmbed {
+	return &dgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       InfoColor,
+		Fields: []*dgo.MessageEmbedField{
+			{Name: "Allowed", Value: describeChannels(g.AllowedChannels, "Every channel"), Inline: false},
+			{Name: "Denied", Value: describeChannels(g.DeniedChannels, "None"), Inline: false},
+		},
+		Type: dgo.EmbedTypeArticle,
+	}
+}
+
+// directMessagesCommand shows or changes whether the bot answers in direct messages
+func directMessagesCommand(req *Request) (*dgo.MessageEmbed, error) {
+	if len(req.Args) == 1 {
+		return directMessagesEmbed("Direct messages", ""), nil
+	}
+
+	answer, ok := map[string]bool{"on": true, "off": false}[strings.ToLower(req.Args[1])]
+	if !ok {
+		err := fmt.Errorf("expected on or off, but got `%v`", req.Args[1])
+		return &dgo.MessageEmbed{
+			Title:       "Invalid arguments",
+			Description: err.Error() + fmt.Sprintf("\nType `%v help channels` for more information", req.Prefix),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	if !req.isOwner() {
+		err := fmt.Errorf("%v is not an owner of the bot", req.Author.Username)
+		return permissionDeniedEmbed("Only the owners of the bot can change whether it answers in direct messages."), err
+	}
+
+	err := settings.Update(directMessagesScope, func(g *GuildSettings) error {
+		g.DirectMessages = &answer
+		return nil
+	})
+	if err != nil {
+		return settingsErrorEmbed(), err
+	}
+
+	description := "The bot no longer answers to commands in direct messages."
+	if answer {
+		description = "The bot now answers to commands in direct messages."
+	}
+	embed := directMessagesEmbed("Direct messages changed", description)
+	embed.Color = SuccessColor
+	return embed, nil
+}
+
+// directMessagesEmbed shows whether the bot answers in direct messages
+func directMessagesEmbed(title, description string) *dgo.MessageEmbed {
+	answered := "No"
+	if answersDirectMessages() {
+		answered = "Yes"
+	}
+	return &dgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       InfoColor,
+		Fields: []*dgo.MessageEmbedField{
+			{Name: "Answered", Value: answered, Inline: false},
+		},
+		Type: dgo.EmbedTypeArticle,
+	}
+}
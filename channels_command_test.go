@@ -0,0 +1,111 @@
+package main
+
+import (
+	"testing"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+func TestChannelLists(t *testing.T) {
+	transport := newFakeTransport()
+	transport.permissions["admin"] = dgo.PermissionManageServer
+
+	// send returns the reply to the message, or nil if the bot ignored it
+	send := func(content, authorID, channelID string) *dgo.MessageEmbed {
+		t.Helper()
+
+		msg := testMessage(content)
+		msg.GuildID = "channels-guild"
+		msg.ChannelID = channelID
+		msg.Author.ID = authorID
+
+		before := len(transport.Sent())
+		handleMessage(transport, testPrefix, msg)
+		sent := transport.Sent()
+		if len(sent) == before {
+			return nil
+		}
+		return sent[len(sent)-1].Embeds[0]
+	}
+
+	checkEmbed(t, send("!bf channels allow <#1>", "author", "2"), "Permission denied", nil)
+	checkEmbed(t, send("!bf channels allow esolangs", "admin", "2"), "Invalid arguments", nil)
+	checkEmbed(t, send("!bf channels allow <#1>", "admin", "2"), "Channels changed", map[string]string{"Allowed": "<#1>", "Denied": "None"})
+
+	if embed := send("!bf help", "author", "2"); embed != nil {
+		t.Errorf("the bot answered %q outside of the allowed channels", embed.Title)
+	}
+	checkEmbed(t, send("!bf help", "author", "1"), "Brainfuck Bot Help", nil)
+
+	checkEmbed(t, send("!bf channels deny <#1>", "admin", "2"), "Channels changed", map[string]string{"Allowed": "Every channel", "Denied": "<#1>"})
+	if embed := send("!bf help", "author", "1"); embed != nil {
+		t.Errorf("the bot answered %q in a denied channel", embed.Title)
+	}
+	checkEmbed(t, send("!bf help", "author", "2"), "Brainfuck Bot Help", nil)
+	checkEmbed(t, send("!bf channels", "author", "1"), "Channels", map[string]string{"Denied": "<#1>"})
+	if embed := send(`!bf exec "abc`, "author", "1"); embed != nil {
+		t.Errorf("the bot answered %q to an invalid command in a denied channel", embed.Title)
+	}
+
+	checkEmbed(t, send("!bf channels reset", "admin", "1"), "Channels changed", map[string]string{"Allowed": "Every channel", "Denied": "None"})
+	checkEmbed(t, send("!bf help", "author", "1"), "Brainfuck Bot Help", nil)
+}
+
+func TestDirectMessagesCommand(t *testing.T) {
+	defer func(owners []string) { botOwners = owners }(botOwners)
+	botOwners = []string{"owner"}
+	// Go back to the direct messages setting of the config once done
+	defer settings.Update(directMessagesScope, func(g *GuildSettings) error {
+		g.DirectMessages = nil
+		return nil
+	})
+
+	transport := newFakeTransport()
+
+	// send returns the reply to the direct message, or nil if the bot ignored it
+	send := func(content, authorID string) *dgo.MessageEmbed {
+		t.Helper()
+
+		msg := testMessage(content)
+		msg.GuildID = ""
+		msg.Author.ID = authorID
+
+		before := len(transport.Sent())
+		handleMessage(transport, testPrefix, msg)
+		sent := transport.Sent()
+		if len(sent) == before {
+			return nil
+		}
+		return sent[len(sent)-1].Embeds[0]
+	}
+
+	checkEmbed(t, send("!bf channels dms", "author"), "Direct messages", map[string]string{"Answered": "Yes"})
+	checkEmbed(t, send("!bf channels dms maybe", "owner"), "Invalid arguments", nil)
+	checkEmbed(t, send("!bf channels dms off", "author"), "Permission denied", nil)
+	checkEmbed(t, send("!bf channels dms off", "owner"), "Direct messages changed", map[string]string{"Answered": "No"})
+
+	if embed := send("!bf help", "author"); embed != nil {
+		t.Errorf("the bot answered %q in a direct message with direct messages disabled", embed.Title)
+	}
+	checkEmbed(t, send("!bf channels dms", "author"), "Direct messages", map[string]string{"Answered": "No"})
+
+	checkEmbed(t, send("!bf channels dms on", "owner"), "Direct messages changed", map[string]string{"Answered": "Yes"})
+	checkEmbed(t, send("!bf help", "author"), "Brainfuck Bot Help", nil)
+}
+
+func TestDirectMessagesScope(t *testing.T) {
+	defer func(answer bool) { answerDirectMessages = answer }(answerDirectMessages)
+
+	answerDirectMessages = false
+	if answersIn("", "dm") {
+		t.Errorf("answersIn() a direct message = true with direct messages disabled")
+	}
+	if !answersCommand("channels", "guild", "dm") {
+		t.Errorf("answersCommand() of channels = false, want it answered everywhere")
+	}
+
+	answerDirectMessages = true
+	if !answersIn("", "dm") {
+		t.Errorf("answersIn() a direct message = false with direct messages enabled")
+	}
+}
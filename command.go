@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+// Request is an invocation of a command, either from a message or a slash command
+type Request struct {
+	// Command being invoked, set when the request is dispatched
+	Command *Command
+	// Name used to invoke the command, which can be one of its aliases
+	Name string
+	// Args are the positional arguments, without the command name and the options
+	Args []string
+	// Options given as `--name=value`, by name, as typed by the user.
+	// Use the typed accessors (e.g. IntOption) to get the validated values.
+	Options map[string]string
+	// Attachments given with the command
+	Attachments []*dgo.MessageAttachment
+
+	Transport Transport
+	// Prefix the bot answers to, to refer to other commands in replies
+	Prefix    string
+	GuildID   string
+	ChannelID string
+	Author    *dgo.User
+	// Roles of the author in the guild, by ID
+	Roles []string
+	// Raw is the command as typed by the user, for logging
+	Raw string
+	// Responder sends the reply to wherever the command came from
+	Responder Responder
+	// Files attached to the reply, for payloads too long for the embed (see payloadField)
+	Files []*dgo.File
+	// Field of the reply split in pages, if any (see pagedField)
+	paged *pagedField
+	// Bytes output by the program run by the request, if it ran successfully. Not nil then,
+	// even if the program output nothing.
+	output []byte
+
+	// Values of the options, converted to their types when the request is validated
+	optionValues map[string]interface{}
+}
+
+// commandHandler runs a command, returning the embed to reply with
+type commandHandler func(req *Request) (*dgo.MessageEmbed, error)
+
+// argSchema describes the positional arguments a command takes
+type argSchema struct {
+	Min int
+	// Max number of arguments, or -1 for no limit
+	Max int
+}
+
+// Param describes a positional argument of a command, shown as an option of the slash command
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+	// Variadic params take the rest of the arguments
+	Variadic bool
+}
+
+// Usage returns how the param is written in the usage of a command, e.g. `<program>`
+func (p *Param) Usage() string {
+	name := p.Name
+	if p.Variadic {
+		name += "..."
+	}
+	if p.Required {
+		return "<" + name + ">"
+	}
+	return "[" + name + "]"
+}
+
+// Command is a command the bot understands
+type Command struct {
+	Name    string
+	Aliases []string
+	// Description is a one line summary shown in the list of commands
+	Description string
+	// Details is the extra information shown in the help of the command, if any
+	Details string
+	Args    argSchema
+	// Params describe the positional arguments, in the order they are given
+	Params []Param
+	// Options are the `--name=value` options the command accepts
+	Options []Option
+	// Attachment describes the file the command accepts as an attachment, if any
+	Attachment string
+	// ProgramAttachment tells if the command takes its program, the last positional
+	// argument, from an attached .bf file
+	ProgramAttachment bool
+	// RunsPrograms tells if the command runs Brainfuck programs, so it waits for one of the
+	// execution workers to be free
+	RunsPrograms bool
+	Handler      commandHandler
+}
+
+// validate checks the positional arguments and options of a request against the command
+func (c *Command) validate(req *Request) error {
+	n := len(req.Args)
+	if n < c.Args.Min || (c.Args.Max >= 0 && n > c.Args.Max) {
+		return fmt.Errorf("wrong number of arguments to %v: expected `%v`, but got %v argument(s)", req.Name, c.Signature(), n)
+	}
+
+	req.optionValues = make(map[string]interface{})
+	for name, value := range req.Options {
+		opt := c.option(name)
+		if opt == nil {
+			return &optionError{Option: name, Reason: fmt.Sprintf("%v does not have this option", c.Name)}
+		}
+
+		v, err := opt.parse(value)
+		if err != nil {
+			return err
+		}
+		req.optionValues[name] = v
+	}
+
+	return nil
+}
+
+// option returns the option of the command with the given name, or nil if there is none
+func (c *Command) option(name string) *Option {
+	for i := range c.Options {
+		if c.Options[i].Name == name {
+			return &c.Options[i]
+		}
+	}
+	return nil
+}
+
+// param returns the param of the command with the given name, or nil if there is none
+func (c *Command) param(name string) *Param {
+	for i := range c.Params {
+		if c.Params[i].Name == name {
+			return &c.Params[i]
+		}
+	}
+	return nil
+}
+
+// Signature returns the name of the command followed by its usage,
+// generated from its options and params
+func (c *Command) Signature() string {
+	parts := []string{c.Name}
+	for i := range c.Options {
+		parts = append(parts, c.Options[i].Usage())
+	}
+	for i := range c.Params {
+		parts = append(parts, c.Params[i].Usage())
+	}
+	return strings.Join(parts, " ")
+}
+
+// registry holds the commands of the bot, indexed by name and alias
+type registry struct {
+	commands []*Command
+	byName   map[string]*Command
+}
+
+func newRegistry() *registry {
+	return &registry{byName: make(map[string]*Command)}
+}
+
+// Register adds commands to the registry.
+// It panics if a name or alias is already taken, since that is a programming error.
+func (r *registry) Register(commands ...*Command) {
+	for _, c := range commands {
+		for _, name := range append([]string{c.Name}, c.Aliases...) {
+			if _, ok := r.byName[name]; ok {
+				panic(fmt.Sprintf("command name %v registered twice", name))
+			}
+			r.byName[name] = c
+		}
+		r.commands = append(r.commands, c)
+	}
+}
+
+// Lookup finds a command by its name or one of its aliases
+func (r *registry) Lookup(name string) (*Command, bool) {
+	c, ok := r.byName[strings.ToLower(name)]
+	return c, ok
+}
+
+// Commands returns the registered commands, sorted by name
+func (r *registry) Commands() []*Command {
+	res := append([]*Command(nil), r.commands...)
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// Commands of the bot
+var commands = newRegistry()
+
+func init() {
+	commands.Register(helpCmd, execCmd, encodeCmd, shortenCmd, configCmd, permsCmd, channelsCmd,
+		saveCmd, runCmd, listCmd, showCmd, deleteCmd, shareCmd, challengeCmd, submitCmd)
+}
+
+// newRequest creates the request for a command from the arguments following the bot prefix,
+// which start with the name of the command, separating the options (`--name=value`) from
+// the positional arguments
+func newRequest(args []string) *Request {
+	req := &Request{
+		Name:    args[0],
+		Options: make(map[string]string),
+	}
+
+	for _, arg := range args[1:] {
+		if name, value, ok := splitOption(arg); ok {
+			req.Options[name] = value
+			continue
+		}
+		req.Args = append(req.Args, arg)
+	}
+
+	return req
+}
@@ -1,48 +1,180 @@
 package main
 
 import (
+	"fmt"
 	"strings"
 	"unicode"
 )
 
-func ParseCommand(command string) []string {
+// ParseCommand splits a command into its arguments, in a similar way to a shell:
+//   - arguments are separated by whitespace
+//   - a single quote at the start of an argument, or of the value of an option like
+//     --input='Hello world\n', groups the text up to the next single quote, keeping the quotes
+//     and the backslashes, so string inputs reach the input parser as typed.
+//     Single quotes anywhere else, or never closed at the start of an argument, are apostrophes
+//     taken literally, like in don't
+//   - text in double quotes is taken literally, except for the escapes \" and \\
+//   - outside quotes, a backslash escapes the character that follows it
+//   - quoted and unquoted text next to each other make a single argument, so
+//     --input="a b" is the argument --input=a b
+//   - Markdown code blocks (`code` or ```code```) are arguments of their own holding the code,
+//     without the language tag of fenced blocks
+//
+// An error is returned if a double quote, or a single quote opening the value of an option, is
+// not closed, along with the arguments before it.
+func ParseCommand(command string) ([]string, error) {
 	var res []string
 	var token strings.Builder
-	var insideQuotes bool
 	insideToken := false
 
+	endToken := func() {
+		if insideToken {
+			res = append(res, token.String())
+			token.Reset()
+			insideToken = false
+		}
+	}
+
 	command = strings.TrimSpace(command)
+	runes := []rune(command)
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
 
-	for _, c := range command {
 		switch {
 		case unicode.IsSpace(c):
-			if insideQuotes {
-				token.WriteRune(c)
-				continue
+			endToken()
+		case c == '\\':
+			insideToken = true
+			if i+1 < len(runes) {
+				i++
+			}
+			token.WriteRune(runes[i])
+		case c == '\'' && (!insideToken || startsOptionValue(token.String())):
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
 			}
 
-			if insideToken {
-				insideToken = false
-				res = append(res, token.String())
-				token.Reset()
+			if end == len(runes) {
+				if insideToken {
+					return res, fmt.Errorf("the single quote at position %v is never closed", i+1)
+				}
+				insideToken = true
+				token.WriteRune(c)
+				continue
 			}
+			insideToken = true
+			token.WriteString(string(runes[i : end+1]))
+			i = end
 		case c == '"':
-			insideQuotes = !insideQuotes
-			if !insideQuotes {
-				res = append(res, token.String())
-				token.Reset()
-				insideToken = false
+			end := i + 1
+			for ; end < len(runes) && runes[end] != '"'; end++ {
+				if runes[end] == '\\' && end+1 < len(runes) && (runes[end+1] == '"' || runes[end+1] == '\\') {
+					end++
+				}
+				token.WriteRune(runes[end])
 			}
+			if end == len(runes) {
+				return res, fmt.Errorf("the double quote at position %v is never closed", i+1)
+			}
+
+			insideToken = true
+			i = end
+		case c == '`':
+			content, end, ok := scanCodeBlock(runes, i)
+			if !ok {
+				insideToken = true
+				token.WriteRune(c)
+				continue
+			}
+
+			// A code block is always an argument of its own
+			endToken()
+			res = append(res, content)
+			i = end - 1
 		default:
 			insideToken = true
 			token.WriteRune(c)
 		}
 	}
 
-	if token.Len() > 0 {
-		res = append(res, token.String())
-		token.Reset()
+	endToken()
+
+	return res, nil
+}
+
+// startsOptionValue tells if the next character of a token is the first one of the value of an
+// option, like after --input=
+func startsOptionValue(token string) bool {
+	return strings.HasPrefix(token, "--") && strings.Index(token, "=") == len(token)-1
+}
+
+// scanCodeBlock scans the Markdown code block opened by the backticks at position start.
+// Blocks can be inline (`code`) or fenced (```code```), and fenced blocks may have a language
+// tag in their first line. It returns the code in the block and the position after the closing
+// backticks, or false if the backticks are not closed.
+func scanCodeBlock(runes []rune, start int) (string, int, bool) {
+	fence := 0
+	for start+fence < len(runes) && runes[start+fence] == '`' {
+		fence++
+	}
+
+	closing := strings.Repeat("`", fence)
+	rest := string(runes[start+fence:])
+	end := strings.Index(rest, closing)
+	if end < 0 {
+		return "", 0, false
+	}
+
+	content := rest[:end]
+	if fence >= 3 {
+		content = stripLanguageTag(content)
+	}
+
+	return content, start + fence + len([]rune(rest[:end])) + fence, true
+}
+
+// stripLanguageTag removes the language tag (e.g. ```bf) from the content of a fenced code block.
+// The first line is only a tag if there is code after it and it looks like a language name,
+// starting with a letter, so programs written in the first line are kept.
+func stripLanguageTag(content string) string {
+	newline := strings.IndexByte(content, '\n')
+	if newline < 0 {
+		return content
+	}
+
+	tag := strings.TrimSpace(content[:newline])
+	if tag == "" {
+		return content[newline+1:]
+	}
+
+	for i, c := range tag {
+		if i == 0 && !unicode.IsLetter(c) {
+			return content
+		}
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune("_+-#.", c) {
+			return content
+		}
+	}
+
+	return content[newline+1:]
+}
+
+// unwrapCodeBlock returns the code inside s if s is a single Markdown code block,
+// or s itself otherwise
+func unwrapCodeBlock(s string) string {
+	trimmed := strings.TrimSpace(s)
+	runes := []rune(trimmed)
+
+	if len(runes) == 0 || runes[0] != '`' {
+		return s
+	}
+
+	content, end, ok := scanCodeBlock(runes, 0)
+	if !ok || end != len(runes) {
+		return s
 	}
 
-	return res
+	return content
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{name: "words", command: "!bf exec +.", want: []string{"!bf", "exec", "+."}},
+		{name: "quotes", command: `!bf exec "1, 2" ,.`, want: []string{"!bf", "exec", "1, 2", ",."}},
+		{name: "inline code", command: "!bf exec `+ + .`", want: []string{"!bf", "exec", "+ + ."}},
+		{name: "fenced code", command: "!bf exec ```\n+\n.```", want: []string{"!bf", "exec", "+\n."}},
+		{name: "fenced code with language", command: "!bf exec ```bf\n+[-]\n.\n```", want: []string{"!bf", "exec", "+[-]\n.\n"}},
+		{name: "fenced code on one line", command: "!bf exec ```+.```", want: []string{"!bf", "exec", "+."}},
+		{name: "code starting with instructions", command: "!bf exec ```++\n.```", want: []string{"!bf", "exec", "++\n."}},
+		{name: "input and code", command: "!bf exec 65 ```bf\n,.```", want: []string{"!bf", "exec", "65", ",."}},
+		{name: "unclosed backtick", command: "!bf exec `+.", want: []string{"!bf", "exec", "`+."}},
+		{name: "single quotes are kept", command: `!bf exec 'a b\n' ,.`, want: []string{"!bf", "exec", `'a b\n'`, ",."}},
+		{name: "single quotes inside double quotes", command: `!bf exec "'a' 10" ,.`, want: []string{"!bf", "exec", `'a' 10`, ",."}},
+		{name: "escaped double quotes", command: `!bf exec "\"a\" \\ \n" ,.`, want: []string{"!bf", "exec", `"a" \ \n`, ",."}},
+		{name: "quotes inside a token", command: `a"b c"d`, want: []string{"ab cd"}},
+		{name: "option with quoted value", command: `!bf exec --out="hex" +.`, want: []string{"!bf", "exec", "--out=hex", "+."}},
+		{name: "escapes outside quotes", command: `a\ b \'c`, want: []string{"a b", "'c"}},
+		{name: "empty quotes", command: `a "" ''`, want: []string{"a", "", "''"}},
+		{name: "backticks inside quotes", command: "'`a`'", want: []string{"'`a`'"}},
+		{name: "apostrophe in a word", command: `!bf encode I'm here`, want: []string{"!bf", "encode", "I'm", "here"}},
+		{name: "apostrophe in a comment", command: `!bf exec +. prints 'a' but doesn't stop`, want: []string{"!bf", "exec", "+.", "prints", "'a'", "but", "doesn't", "stop"}},
+		{name: "unclosed single quote", command: `!bf exec +. 'tis`, want: []string{"!bf", "exec", "+.", "'tis"}},
+		{name: "single quoted option", command: `!bf exec --input='a b' ,.`, want: []string{"!bf", "exec", `--input='a b'`, ",."}},
+		{name: "single quoted option keeps escapes", command: `!bf exec --input='a\n' ,.`, want: []string{"!bf", "exec", `--input='a\n'`, ",."}},
+		{name: "unterminated single quoted option", command: `!bf exec --input='abc ,.`, want: []string{"!bf", "exec"}, wantErr: true},
+		{name: "unterminated double quote", command: `!bf exec "abc`, want: []string{"!bf", "exec"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCommand(tt.command)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// quoteArgument quotes an argument so ParseCommand gives it back unchanged
+func quoteArgument(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+func FuzzParseCommand(f *testing.F) {
+	for _, seed := range []string{
+		"!bf exec +.",
+		`!bf exec "'a b'" ,.`,
+		"!bf exec ```bf\n+[-]```",
+		`a"b c"d \" '\'`,
+		"`",
+		`"\`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		// Must never panic, whatever the input
+		ParseCommand(s)
+
+		if !utf8.ValidString(s) {
+			return
+		}
+
+		// Any argument quoted survives the parsing untouched
+		got, err := ParseCommand("!bf " + quoteArgument(s) + " " + quoteArgument(s))
+		if err != nil {
+			t.Fatalf("ParseCommand() of quoted %q failed: %v", s, err)
+		}
+		if want := []string{"!bf", s, s}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("ParseCommand() of quoted %q = %q, want %q", s, got, want)
+		}
+	})
+}
+
+func TestUnwrapCodeBlock(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "+.", want: "+."},
+		{in: "`+.`", want: "+."},
+		{in: "```brainfuck\n+.```", want: "+."},
+		{in: "`+.` `-`", want: "`+.` `-`"},
+	}
+	for _, tt := range tests {
+		if got := unwrapCodeBlock(tt.in); got != tt.want {
+			t.Errorf("unwrapCodeBlock(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantArgs    []string
+		wantOptions map[string]string
+	}{
+		{
+			name:        "positional only",
+			args:        []string{"exec", "65", ",."},
+			wantArgs:    []string{"65", ",."},
+			wantOptions: map[string]string{},
+		},
+		{
+			name:        "option",
+			args:        []string{"exec", "--out=hex", "+."},
+			wantArgs:    []string{"+."},
+			wantOptions: map[string]string{"out": "hex"},
+		},
+		{
+			name:        "flag",
+			args:        []string{"exec", "--dump", "+."},
+			wantArgs:    []string{"+."},
+			wantOptions: map[string]string{"dump": ""},
+		},
+		{
+			name:        "program starting with dashes",
+			args:        []string{"exec", "--[=+]", "--=."},
+			wantArgs:    []string{"--[=+]", "--=."},
+			wantOptions: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(tt.args)
+			if !reflect.DeepEqual(req.Args, tt.wantArgs) {
+				t.Errorf("newRequest() args = %q, want %q", req.Args, tt.wantArgs)
+			}
+			if !reflect.DeepEqual(req.Options, tt.wantOptions) {
+				t.Errorf("newRequest() options = %v, want %v", req.Options, tt.wantOptions)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	for _, name := range []string{"help", "exec", "encode", "shorten", "short"} {
+		if _, ok := commands.Lookup(name); !ok {
+			t.Errorf("Lookup(%q) did not find the command", name)
+		}
+	}
+
+	short, _ := commands.Lookup("short")
+	if short != shortenCmd {
+		t.Errorf("Lookup(%q) = %v, want the shorten command", "short", short.Name)
+	}
+
+	if _, ok := commands.Lookup("nope"); ok {
+		t.Errorf("Lookup(%q) found a command", "nope")
+	}
+}
+
+func TestCommandValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "ok", args: []string{"exec", "+."}},
+		{name: "too few", args: []string{"exec"}, wantErr: true},
+		{name: "too many", args: []string{"exec", "1", "2", "3"}, wantErr: true},
+		{name: "unknown option", args: []string{"exec", "--nope=1", "+."}, wantErr: true},
+		{name: "int option", args: []string{"exec", "--limit=100", "+."}},
+		{name: "int option out of range", args: []string{"exec", "--limit=0", "+."}, wantErr: true},
+		{name: "int option not a number", args: []string{"exec", "--cells=x", "+."}, wantErr: true},
+		{name: "flag", args: []string{"exec", "--dump", "+."}},
+		{name: "flag set to false", args: []string{"exec", "--dump=false", "+."}},
+		{name: "flag with bad value", args: []string{"exec", "--dump=maybe", "+."}, wantErr: true},
+		{name: "choice", args: []string{"exec", "--out=HEX", "+."}},
+		{name: "bad choice", args: []string{"exec", "--out=nope", "+."}, wantErr: true},
+		{name: "string option without value", args: []string{"exec", "--input", "+."}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := execCmd.validate(newRequest(tt.args))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommandOptionValues(t *testing.T) {
+	req := newRequest([]string{"exec", "--out=HEX", "--limit=100", "--dump", "+."})
+	if err := execCmd.validate(req); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+
+	if out, ok := req.StringOption("out"); !ok || out != "hex" {
+		t.Errorf("StringOption(out) = %q, %v, want hex, true", out, ok)
+	}
+	if limit := req.IntOption("limit", 0); limit != 100 {
+		t.Errorf("IntOption(limit) = %v, want 100", limit)
+	}
+	if cells := req.IntOption("cells", 7); cells != 7 {
+		t.Errorf("IntOption(cells) = %v, want the default 7", cells)
+	}
+	if !req.BoolOption("dump") {
+		t.Errorf("BoolOption(dump) = false, want true")
+	}
+}
+
+func TestSignature(t *testing.T) {
+	tests := []struct {
+		cmd  *Command
+		want string
+	}{
+		{cmd: execCmd, want: "exec [--out=<utf8|latin1|dec|hex>] [--input=<input>] [--limit=<n>] [--cells=<n>] [--dump] [input] <program>"},
+		{cmd: encodeCmd, want: "encode [--format=<bytes|codepoints>] [text...]"},
+		{cmd: shortenCmd, want: "shorten <program>"},
+		{cmd: helpCmd, want: "help [command]"},
+	}
+	for _, tt := range tests {
+		if got := tt.cmd.Signature(); got != tt.want {
+			t.Errorf("Signature() = %q, want %q", got, tt.want)
+		}
+	}
+}
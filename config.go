@@ -1,7 +1,10 @@
 package main
 
 import (
+	bf "brainfuck-discord-bot/brainfuck"
 	"fmt"
+	"runtime"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -19,6 +22,17 @@ func readConfig() error {
 
 	// Setup defaults
 	viper.SetDefault("bot_prefix", "!bf")
+	viper.SetDefault("data_dir", "data")
+	viper.SetDefault("direct_messages", true)
+	viper.SetDefault("rate_limit.user.burst", defaultUserBurst)
+	viper.SetDefault("rate_limit.user.per_minute", defaultUserPerMinute)
+	viper.SetDefault("rate_limit.guild.burst", defaultGuildBurst)
+	viper.SetDefault("rate_limit.guild.per_minute", defaultGuildPerMinute)
+	viper.SetDefault("executions.workers", runtime.NumCPU())
+	viper.SetDefault("executions.queue", defaultExecutionQueue)
+	viper.SetDefault("limits.max_instructions", bf.MaxExecInstructions)
+	viper.SetDefault("limits.max_memory", bf.MaxMemory)
+	viper.SetDefault("limits.max_timeout", defaultMaxTimeout)
 
 	err := viper.ReadInConfig()
 	if err != nil {
@@ -36,5 +50,19 @@ func readConfig() error {
 			"directory with a key 'bot_token' with the token or define an env variable BF_DISCORD_BOT_TOKEN containing the token")
 	}
 
+	for _, key := range []string{"rate_limit.user.burst", "rate_limit.user.per_minute", "rate_limit.guild.burst",
+		"rate_limit.guild.per_minute", "executions.workers", "limits.max_instructions", "limits.max_memory"} {
+		if viper.GetFloat64(key) <= 0 {
+			return fmt.Errorf("%v must be positive, but it is %v", key, viper.Get(key))
+		}
+	}
+	if viper.GetInt("executions.queue") < 0 {
+		return fmt.Errorf("executions.queue can not be negative, but it is %v", viper.Get("executions.queue"))
+	}
+
+	if viper.GetDuration("limits.max_timeout") < time.Second {
+		return fmt.Errorf("limits.max_timeout must be at least 1s, but it is %v", viper.Get("limits.max_timeout"))
+	}
+
 	return nil
 }
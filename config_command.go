@@ -0,0 +1,261 @@
+package main
+
+import (
+	bf "brainfuck-discord-bot/brainfuck"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+// guildSetting is a setting of a guild that can be shown and changed with the config command
+type guildSetting struct {
+	Name        string
+	Description string
+	// Show returns the value of the setting in the guild
+	Show func(req *Request, g GuildSettings) string
+	// Set changes the setting, or resets it to its default if value is "reset"
+	Set func(g *GuildSettings, value string) error
+}
+
+// Settings of a guild, in the order they are listed
+var guildSettings = []guildSetting{
+	{
+		Name:        "prefix",
+		Description: "The prefix the bot answers to in this server. Mentioning the bot always works too.",
+		Show: func(req *Request, g GuildSettings) string {
+			return "`" + guildPrefix(req.GuildID, bot_prefix) + "`"
+		},
+		Set: func(g *GuildSettings, value string) error {
+			if value == "reset" {
+				g.Prefix = ""
+				return nil
+			}
+			if err := validatePrefix(value); err != nil {
+				return err
+			}
+			g.Prefix = value
+			return nil
+		},
+	},
+	{
+		Name:        "instructions",
+		Description: "Max number of instructions a program can execute.",
+		Show: func(req *Request, g GuildSettings) string {
+			config, _ := execConfig(g)
+			return strconv.Itoa(config.MaxInstructions)
+		},
+		Set: func(g *GuildSettings, value string) error {
+			if value == "reset" {
+				g.MaxInstructions = 0
+				return nil
+			}
+			n, err := parseLimit(value, execCeilings.MaxInstructions)
+			if err != nil {
+				return err
+			}
+			g.MaxInstructions = n
+			return nil
+		},
+	},
+	{
+		Name:        "memory",
+		Description: "Max number of memory cells a program can use.",
+		Show: func(req *Request, g GuildSettings) string {
+			config, _ := execConfig(g)
+			return strconv.Itoa(config.MaxMemory)
+		},
+		Set: func(g *GuildSettings, value string) error {
+			if value == "reset" {
+				g.MaxMemory = 0
+				return nil
+			}
+			n, err := parseLimit(value, execCeilings.MaxMemory)
+			if err != nil {
+				return err
+			}
+			g.MaxMemory = n
+			return nil
+		},
+	},
+	{
+		Name:        "timeout",
+		Description: "Max number of seconds a program can run for.",
+		Show: func(req *Request, g GuildSettings) string {
+			_, timeout := execConfig(g)
+			return timeout.String()
+		},
+		Set: func(g *GuildSettings, value string) error {
+			if value == "reset" {
+				g.TimeoutSeconds = 0
+				return nil
+			}
+			n, err := parseLimit(strings.TrimSuffix(value, "s"), int(execCeilings.Timeout/time.Second))
+			if err != nil {
+				return err
+			}
+			g.TimeoutSeconds = n
+			return nil
+		},
+	},
+	{
+		Name:        "cell-width",
+		Description: "Bits of the memory cells: 8 (default), 16 or 32. Wider cells output the Unicode character they hold.",
+		Show: func(req *Request, g GuildSettings) string {
+			config, _ := execConfig(g)
+			return fmt.Sprintf("%v bits", config.CellWidth)
+		},
+		Set: func(g *GuildSettings, value string) error {
+			if value == "reset" {
+				g.CellWidth = 0
+				return nil
+			}
+			w, err := bf.ParseCellWidth(value)
+			if err != nil {
+				return err
+			}
+			g.CellWidth = int(w)
+			return nil
+		},
+	},
+	{
+		Name:        "eof",
+		Description: "What reading past the end of the input does: `error` (default), `zero`, `minus-one` or `unchanged`.",
+		Show: func(req *Request, g GuildSettings) string {
+			config, _ := execConfig(g)
+			return "`" + config.EOF.String() + "`"
+		},
+		Set: func(g *GuildSettings, value string) error {
+			if value == "reset" {
+				g.EOF = ""
+				return nil
+			}
+			mode, err := bf.ParseEOFMode(value)
+			if err != nil {
+				return err
+			}
+			g.EOF = mode.String()
+			return nil
+		},
+	},
+}
+
+var configCmd = &Command{
+	Name:        "config",
+	Description: "Shows or changes the settings of the server",
+	Details:     configDetails(),
+	Args:        argSchema{Min: 1, Max: 2},
+	Params: []Param{
+		{Name: "setting", Description: "The setting to show or change, or show to list them all", Required: true},
+		{Name: "value", Description: "The new value of the setting, or reset to go back to the default"},
+	},
+	Handler: configCommand,
+}
+
+// configDetails lists the settings in the help of the config command
+func configDetails() string {
+	var details strings.Builder
+	for _, s := range guildSettings {
+		fmt.Fprintf(&details, "`%v` - %v\n", s.Name, s.Description)
+	}
+	details.WriteString("`show` lists the settings of the server. Changing a setting requires the Manage Server permission. Use `reset` as the value to go back to the default.\n")
+	details.WriteString("The limits can not be raised over the limits of the bot.")
+	return details.String()
+}
+
+// findGuildSetting finds a setting of the guilds by name
+func findGuildSetting(name string) (*guildSetting, bool) {
+	for i := range guildSettings {
+		if strings.EqualFold(guildSettings[i].Name, name) {
+			return &guildSettings[i], true
+		}
+	}
+	return nil, false
+}
+
+func configCommand(req *Request) (*dgo.MessageEmbed, error) {
+	if embed, err := requireGuild(req, "The settings"); embed != nil {
+		return embed, err
+	}
+
+	if strings.EqualFold(req.Args[0], "show") && len(req.Args) == 1 {
+		return showSettings(req), nil
+	}
+
+	setting, ok := findGuildSetting(req.Args[0])
+	if !ok {
+		err := fmt.Errorf("there is no setting named `%v`", req.Args[0])
+		return &dgo.MessageEmbed{
+			Title:       "Unknown setting",
+			Description: err.Error() + fmt.Sprintf("\nType `%v help config` to see the settings", req.Prefix),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	if len(req.Args) == 1 {
+		return &dgo.MessageEmbed{
+			Title:       fmt.Sprintf("Setting %v", setting.Name),
+			Description: setting.Description,
+			Color:       InfoColor,
+			Fields: []*dgo.MessageEmbedField{
+				{Name: "Value", Value: setting.Show(req, settings.Get(req.GuildID)), Inline: false},
+			},
+			Type: dgo.EmbedTypeArticle,
+		}, nil
+	}
+
+	if embed, err := requireAdmin(req, "Changing the settings of the server"); embed != nil {
+		return embed, err
+	}
+
+	var setErr error
+	err := settings.Update(req.GuildID, func(g *GuildSettings) error {
+		setErr = setting.Set(g, req.Args[1])
+		return setErr
+	})
+	if setErr != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Invalid value",
+			Description: setErr.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, setErr
+	}
+	if err != nil {
+		return settingsErrorEmbed(), err
+	}
+
+	// The prefix may have changed, so the replies refer to the new one
+	req.Prefix = guildPrefix(req.GuildID, bot_prefix)
+
+	return &dgo.MessageEmbed{
+		Title:       "Setting changed",
+		Description: fmt.Sprintf("The %v setting of this server was changed.", setting.Name),
+		Color:       SuccessColor,
+		Fields: []*dgo.MessageEmbedField{
+			{Name: "Value", Value: setting.Show(req, settings.Get(req.GuildID)), Inline: false},
+		},
+		Type: dgo.EmbedTypeArticle,
+	}, nil
+}
+
+// showSettings lists the effective value of every setting of the guild of the request
+func showSettings(req *Request) *dgo.MessageEmbed {
+	g := settings.Get(req.GuildID)
+
+	fields := make([]*dgo.MessageEmbedField, 0, len(guildSettings))
+	for _, s := range guildSettings {
+		fields = append(fields, &dgo.MessageEmbedField{Name: s.Name, Value: s.Show(req, g), Inline: true})
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Settings",
+		Description: fmt.Sprintf("Settings of this server. Type `%v help config` to see what they do.", req.Prefix),
+		Color:       InfoColor,
+		Fields:      fields,
+		Type:        dgo.EmbedTypeArticle,
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	bf "brainfuck-discord-bot/brainfuck"
+	"testing"
+	"time"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+func TestValidatePrefix(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		wantErr bool
+	}{
+		{prefix: "?"},
+		{prefix: "!bf"},
+		{prefix: "", wantErr: true},
+		{prefix: "way too long", wantErr: true},
+		{prefix: "a b", wantErr: true},
+		{prefix: "'", wantErr: true},
+		{prefix: "<@1>", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validatePrefix(tt.prefix); (err != nil) != tt.wantErr {
+			t.Errorf("validatePrefix(%q) error = %v, wantErr %v", tt.prefix, err, tt.wantErr)
+		}
+	}
+}
+
+func TestConfigPrefix(t *testing.T) {
+	defer func(id string) { botUserID = id }(botUserID)
+	botUserID = "bot"
+
+	transport := newFakeTransport()
+	transport.permissions["admin"] = dgo.PermissionManageServer
+
+	send := func(content, authorID string) *dgo.MessageEmbed {
+		t.Helper()
+
+		msg := testMessage(content)
+		msg.GuildID = "prefix-guild"
+		msg.Author.ID = authorID
+
+		before := len(transport.Sent())
+		handleMessage(transport, testPrefix, msg)
+		sent := transport.Sent()
+		if len(sent) == before {
+			return nil
+		}
+		return sent[len(sent)-1].Embeds[0]
+	}
+
+	checkEmbed(t, send("!bf config prefix ?", "author"), "Permission denied", nil)
+	checkEmbed(t, send("!bf config prefix 'a b'", "admin"), "Invalid value", nil)
+	checkEmbed(t, send("!bf config prefix ?", "admin"), "Setting changed", map[string]string{"Value": "`?`"})
+
+	if embed := send("!bf help", "author"); embed != nil {
+		t.Errorf("the default prefix got the reply %q after changing the prefix", embed.Title)
+	}
+	checkEmbed(t, send("? config prefix", "author"), "Setting prefix", map[string]string{"Value": "`?`"})
+	checkEmbed(t, send("<@bot> help", "author"), "Brainfuck Bot Help", nil)
+	checkEmbed(t, send("<@!bot>", "author"), "Brainfuck Bot Help", nil)
+
+	checkEmbed(t, send("<@bot> config prefix reset", "admin"), "Setting changed", map[string]string{"Value": "`!bf`"})
+	checkEmbed(t, send("!bf help", "author"), "Brainfuck Bot Help", nil)
+}
+
+func TestConfigExecLimits(t *testing.T) {
+	transport := newFakeTransport()
+	transport.permissions["admin"] = dgo.PermissionManageServer
+
+	send := func(content, authorID string) *dgo.MessageEmbed {
+		t.Helper()
+
+		msg := testMessage(content)
+		msg.GuildID = "limits-guild"
+		msg.Author.ID = authorID
+
+		handleMessage(transport, testPrefix, msg)
+		sent := transport.Sent()
+		return sent[len(sent)-1].Embeds[0]
+	}
+
+	checkEmbed(t, send("!bf config show", "author"), "Settings", map[string]string{
+		"instructions": "10000000",
+		"memory":       "30000",
+		"timeout":      "30s",
+		"cell-width":   "8 bits",
+		"eof":          "`error`",
+	})
+
+	checkEmbed(t, send("!bf config instructions 100000000", "admin"), "Invalid value", nil)
+	checkEmbed(t, send("!bf config instructions 100", "admin"), "Setting changed", map[string]string{"Value": "100"})
+	checkEmbed(t, send("!bf exec +[]", "author"), "Execution error", nil)
+	checkEmbed(t, send("!bf exec --limit=1000 +", "author"), "Invalid option", nil)
+	checkEmbed(t, send("!bf exec --limit=50 +", "author"), "Execution successful", nil)
+	checkEmbed(t, send("!bf config instructions reset", "admin"), "Setting changed", map[string]string{"Value": "10000000"})
+
+	checkEmbed(t, send("!bf exec ,.", "author"), "Execution error", nil)
+	checkEmbed(t, send("!bf config eof zero", "admin"), "Setting changed", map[string]string{"Value": "`zero`"})
+	checkEmbed(t, send("!bf exec --out=dec +,.", "author"), "Execution successful", map[string]string{"Output": "0"})
+
+	checkEmbed(t, send("!bf config cell-width 12", "admin"), "Invalid value", nil)
+	checkEmbed(t, send("!bf config cell-width 16", "admin"), "Setting changed", map[string]string{"Value": "16 bits"})
+	checkEmbed(t, send("!bf exec --out=hex -.", "author"), "Execution successful", map[string]string{"Output": "ef bf bf"})
+
+	checkEmbed(t, send("!bf config timeout 60", "admin"), "Invalid value", nil)
+	checkEmbed(t, send("!bf config timeout 5s", "admin"), "Setting changed", map[string]string{"Value": "5s"})
+}
+
+func TestExecConfigCapsLimits(t *testing.T) {
+	defer func(c execLimits) { execCeilings = c }(execCeilings)
+	execCeilings = execLimits{MaxInstructions: 1000, MaxMemory: 100, Timeout: 10 * time.Second}
+
+	config, timeout := execConfig(GuildSettings{MaxInstructions: 5000, MaxMemory: 50, TimeoutSeconds: 60, CellWidth: 32, EOF: "unchanged"})
+	want := bf.Config{MaxInstructions: 1000, MaxMemory: 50, CellWidth: bf.Cell32, EOF: bf.EOFUnchanged}
+	if config != want || timeout != 10*time.Second {
+		t.Errorf("execConfig() = %+v, %v, want %+v, %v", config, timeout, want, 10*time.Second)
+	}
+}
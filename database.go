@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// openDatabase opens the database of the bot at path, creating it if it does not exist.
+// The database holds the data users create, like snippets, while the settings of the guilds
+// are kept in their own file (see settingsStore).
+func openDatabase(path string) (*bolt.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("could not create the data directory: %v", err)
+	}
+
+	// The timeout fails fast if another instance of the bot has the database open
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("could not open the database %v: %v", path, err)
+	}
+	return db, nil
+}
@@ -8,19 +8,49 @@ import (
 	dgo "github.com/bwmarrin/discordgo"
 )
 
-func validateEncodeArgs(args ...string) (bool, error) {
-	n := len(args)
-	if n == 1 {
-		return false, fmt.Errorf("wrong number of arguments to encode: expected 1 `encode <desired_output>`, but got none")
+var encodeCmd = &Command{
+	Name:        "encode",
+	Description: "Creates a Brainfuck program that outputs the characters in the target output, or the bytes of an attached file",
+	Details: "With `--format=bytes` the program outputs the UTF-8 bytes of the text. " +
+		"With `--format=codepoints` it writes one code point per cell instead, for interpreters with cells wider than a byte.",
+	Args: argSchema{Min: 0, Max: -1},
+	Params: []Param{
+		{Name: "text", Description: "The text the program should output", Variadic: true},
+	},
+	Options: []Option{
+		{Name: "format", Description: "How the text is written to memory: bytes (default) or codepoints", Choices: []string{"bytes", "codepoints"}},
+	},
+	Attachment: "File whose bytes the program should output",
+	Handler:    encodeCommand,
+}
+
+func validateEncodeArgs(req *Request) (bool, error) {
+	n := len(req.Args)
+	if n == 0 && len(req.Attachments) == 0 {
+		return false, fmt.Errorf("wrong number of arguments to encode: expected 1 `encode <desired_output>` or an attached file, but got none")
+	}
+	if n > 0 && len(req.Attachments) > 0 {
+		return false, fmt.Errorf("encode takes either a text or an attached file, but got both")
+	}
+	if len(req.Attachments) > 1 {
+		return false, fmt.Errorf("encode takes a single attached file, but got %v", len(req.Attachments))
 	}
 	return true, nil
 }
 
-func encodeCommand(args ...string) (*dgo.MessageEmbed, error) {
+// encodeMode returns the encoding selected with the --format option
+func encodeMode(req *Request) bf.EncodeMode {
+	if format, _ := req.StringOption("format"); format == "codepoints" {
+		return bf.CodePointEncoding
+	}
+	return bf.ByteEncoding
+}
+
+func encodeCommand(req *Request) (*dgo.MessageEmbed, error) {
 	var err error
 	var ok bool
 
-	if ok, err = validateExecArgs(args...); !ok {
+	if ok, err = validateEncodeArgs(req); !ok {
 		return &dgo.MessageEmbed{
 			Title:       "Invalid number of arguments",
 			Description: err.Error(),
@@ -29,14 +59,39 @@ func encodeCommand(args ...string) (*dgo.MessageEmbed, error) {
 		}, err
 	}
 
-	textToEncode := strings.Join(args[1:], " ")
-	bfProgram := bf.Encode(textToEncode)
+	var bfProgram, target string
+
+	if len(req.Attachments) == 1 {
+		data, err := fetchAttachment(req.Attachments[0])
+		if err != nil {
+			return &dgo.MessageEmbed{
+				Title:       "Attachment error",
+				Description: err.Error(),
+				Color:       ErrorColor,
+				Type:        dgo.EmbedTypeArticle,
+			}, err
+		}
+
+		bfProgram = bf.EncodeBytes(data)
+		target = fmt.Sprintf("%v bytes from %v", len(data), req.Attachments[0].Filename)
+	} else {
+		target = strings.Join(req.Args, " ")
+		bfProgram, err = bf.Encode(target, encodeMode(req))
+		if err != nil {
+			return &dgo.MessageEmbed{
+				Title:       "Encoding error",
+				Description: err.Error(),
+				Color:       ErrorColor,
+				Type:        dgo.EmbedTypeArticle,
+			}, fmt.Errorf("encoding error: %v", err)
+		}
+	}
 
 	return &dgo.MessageEmbed{
 		Color: SuccessColor,
 		Fields: []*dgo.MessageEmbedField{
-			{Name: "Target output", Value: textToEncode, Inline: false},
-			{Name: "Brainfuck Program", Value: bfProgram, Inline: false},
+			req.payloadField("Target output", target, "target.txt"),
+			req.payloadField("Brainfuck Program", bfProgram, "program.bf"),
 		},
 		Type: dgo.EmbedTypeArticle,
 	}, err
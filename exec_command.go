@@ -2,44 +2,145 @@ package main
 
 import (
 	bf "brainfuck-discord-bot/brainfuck"
+	"bytes"
+	"context"
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
 	dgo "github.com/bwmarrin/discordgo"
 )
 
-func validateExecArgs(args ...string) (bool, error) {
-	n := len(args)
-	if n == 1 || n > 3 {
-		return false, fmt.Errorf("wrong number of arguments to exec: expected 1 `exec <program>` or 2 `exec [input] <program>`, but got %v", n-1)
+var execCmd = &Command{
+	Name:        "exec",
+	Description: "Executes a brainfuck program",
+	Details: "The output mode can be `utf8` (default), `latin1`, `dec` or `hex`.\n" +
+		"The input is a list of numbers (`65,0x42`) and quoted strings, given as an argument, with `--input=` or as an attached text file. " +
+		"Strings with spaces are written as they are, like `'abc def\\n'`, and double quotes group an input of several items, like `\"'abc' 10\"`.\n" +
+		"The program can be given in a code block or as an attached `.bf` file.\n" +
+		"Programs that take a while show their output as they run, and can be stopped with the " + stopEmoji + " reaction.\n" +
+		"`--limit` and `--cells` lower the instructions and memory cells the program can use, and `--dump` shows the memory after the run.\n" +
+		"The limits, the cell width and what reading past the end of the input does are settings of the server, see `config show`.",
+	Args: argSchema{Min: 1, Max: 2},
+	Params: []Param{
+		{Name: "input", Description: "Numbers and quoted strings fed to the program, like 65,0x42,'abc'"},
+		{Name: "program", Description: "The Brainfuck program to run, unless attached as a .bf file", Required: true},
+	},
+	Options: []Option{
+		{Name: "out", Description: "How to show the output: utf8 (default), latin1, dec or hex", Choices: []string{"utf8", "latin1", "dec", "hex"}},
+		{Name: "input", Description: "Numbers and quoted strings fed to the program, instead of the input argument"},
+		{Name: "limit", Description: "Max number of instructions to execute, up to the limit of the server", Type: IntOption, Min: 1, Max: math.MaxInt32},
+		{Name: "cells", Description: "Max number of memory cells the program can use, up to the limit of the server", Type: IntOption, Min: 1, Max: math.MaxInt32},
+		{Name: "dump", Description: "Show the memory cells after the program runs", Type: BoolOption},
+	},
+	Attachment:        "Text file whose bytes are used as the input, or .bf file with the program",
+	ProgramAttachment: true,
+	RunsPrograms:      true,
+	Handler:           execCommand,
+}
+
+// Max number of memory cells shown by --dump
+const maxDumpCells = 50
+
+func validateExecArgs(req *Request) (bool, error) {
+	if len(req.Attachments) > 1 {
+		return false, fmt.Errorf("exec takes a single attached input file, but got %v", len(req.Attachments))
+	}
+
+	sources := len(req.Attachments)
+	if len(req.Args) == 2 {
+		sources++
+	}
+	if _, ok := req.StringOption("input"); ok {
+		sources++
+	}
+	if sources > 1 {
+		return false, fmt.Errorf("exec takes the input either as an argument, with `--input` or as an attached file, but got more than one")
 	}
 	return true, nil
 }
 
-func execCommand(args ...string) (*dgo.MessageEmbed, error) {
-	if ok, err := validateExecArgs(args...); !ok {
+// execLimitsOf returns the config the program of the request runs with, and the time it can run
+// for: the settings of the guild, lowered by the `--limit` and `--cells` options if given.
+// The options can not raise the limits of the guild.
+func execLimitsOf(req *Request) (bf.Config, time.Duration, error) {
+	config, timeout := guildExecConfig(req.GuildID)
+
+	limit := req.IntOption("limit", config.MaxInstructions)
+	if limit > config.MaxInstructions {
+		return config, timeout, &optionError{Option: "limit", Reason: fmt.Sprintf("programs can execute at most %v instructions here", config.MaxInstructions)}
+	}
+	cells := req.IntOption("cells", config.MaxMemory)
+	if cells > config.MaxMemory {
+		return config, timeout, &optionError{Option: "cells", Reason: fmt.Sprintf("programs can use at most %v memory cells here", config.MaxMemory)}
+	}
+
+	config.MaxInstructions = limit
+	config.MaxMemory = cells
+	return config, timeout, nil
+}
+
+// execInputs gets the input for the program, either from the attached text file or
+// from the input argument or option (see parseInput for the syntax).
+// The bytes of an attached file are read once, while the values of the input argument
+// are fed to the program in a cyclic manner.
+func execInputs(req *Request) (bf.InputProvider, error) {
+	if len(req.Attachments) == 1 {
+		data, err := fetchAttachment(req.Attachments[0])
+		if err != nil {
+			return nil, err
+		}
+		return bf.ReaderInput(bytes.NewReader(data)), nil
+	}
+
+	text, ok := req.StringOption("input")
+	if len(req.Args) == 2 {
+		text, ok = req.Args[0], true
+	}
+
+	var inputs []int
+	if ok {
+		var err error
+		if inputs, err = parseInput(text); err != nil {
+			return nil, err
+		}
+	}
+
+	return bf.CyclicInput(inputs...), nil
+}
+
+func execCommand(req *Request) (*dgo.MessageEmbed, error) {
+	outMode := bf.UTF8Output
+	if out, ok := req.StringOption("out"); ok {
+		outMode, _ = bf.ParseOutputMode(out)
+	}
+
+	if ok, err := validateExecArgs(req); !ok {
 		return &dgo.MessageEmbed{
-			Title:       "Invalid number of arguments",
+			Title:       "Invalid arguments",
 			Description: err.Error(),
 			Color:       ErrorColor,
 			Type:        dgo.EmbedTypeArticle,
 		}, err
 	}
 
-	var p *bf.Program
-	var err error
-
-	nArgs := len(args)
+	config, timeout, err := execLimitsOf(req)
+	if err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Invalid option",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
 
 	start := time.Now()
-	if nArgs == 3 {
-		p, err = bf.Compile(args[2])
-	} else {
-		p, err = bf.Compile(args[1])
-	}
+	p, err := bf.Compile(req.Args[len(req.Args)-1])
 	elapsedCompilation := time.Now().Sub(start)
 
 	if err != nil {
@@ -51,32 +152,41 @@ func execCommand(args ...string) (*dgo.MessageEmbed, error) {
 		}, fmt.Errorf("compilation error: %v", err)
 	}
 
-	var out *bf.ExecutionResult
-	var elapsedExecute time.Duration
-	if nArgs == 3 {
-		var inputs []int
-		strInputs := strings.Split(args[1], ",")
-		for _, inp := range strInputs {
-			intInput, err := strconv.Atoi(inp)
-			if err != nil {
-				annotatedError := fmt.Errorf("could not parse input %v as int: %v", inp, err)
-				return &dgo.MessageEmbed{
-					Title:       "Input parsing error",
-					Description: annotatedError.Error(),
-					Color:       ErrorColor,
-					Type:        dgo.EmbedTypeArticle,
-				}, annotatedError
-			}
-			inputs = append(inputs, intInput)
+	input, err := execInputs(req)
+	if err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Input parsing error",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	start = time.Now()
+	output, out, err := runLive(req, p, bf.RunOptions{Input: input, Config: config}, timeout, outMode)
+	elapsedExecute := time.Now().Sub(start)
+
+	if err == context.Canceled {
+		partialOutput := bf.DecodeOutput(output, outMode)
+		if partialOutput == "" {
+			partialOutput = "No output"
 		}
 
-		start := time.Now()
-		out, err = p.Execute(inputs...)
-		elapsedExecute = time.Now().Sub(start)
-	} else {
-		start := time.Now()
-		out, err = p.Execute()
-		elapsedExecute = time.Now().Sub(start)
+		return &dgo.MessageEmbed{
+			Title:       "Execution stopped",
+			Description: "The program was stopped before it finished.",
+			Color:       InfoColor,
+			Fields: []*dgo.MessageEmbedField{
+				req.payloadField("Output so far", partialOutput, "output.txt"),
+				{Name: "Execution in", Value: elapsedExecute.String(), Inline: true},
+				{Name: "Instructions", Value: strconv.Itoa(out.InstructionsExecuted), Inline: true},
+			},
+			Type: dgo.EmbedTypeArticle,
+		}, fmt.Errorf("execution stopped by the user")
+	}
+
+	if err == context.DeadlineExceeded {
+		err = fmt.Errorf("the program ran for longer than the time limit of %v", timeout)
 	}
 
 	if err != nil {
@@ -88,32 +198,66 @@ func execCommand(args ...string) (*dgo.MessageEmbed, error) {
 		}, fmt.Errorf("execution error: %v", err)
 	}
 
-	finalOutput := out.Output
+	finalOutput := bf.DecodeOutput(output, outMode)
 	description := "Program ran successfully."
 
-	// TODO: see better ways to check last rune
-	if len(out.Output) > 0 && unicode.IsSpace(rune(out.Output[len(out.Output)-1])) {
+	// Outputs sent as a file keep their whitespace, so the marker is only needed in the embed
+	if lastRune, _ := utf8.DecodeLastRuneInString(finalOutput); len(finalOutput) > 0 && unicode.IsSpace(lastRune) && fitsField(finalOutput+"<EOF>") {
 		finalOutput += "<EOF>"
 		description = "Program ran successfully. Since the output ends in whitespace, an explicit <EOF> was introduced for you"
 	}
 
-	if len(out.Output) == 0 {
+	if len(output) == 0 {
 		finalOutput = "No output"
 		description = "Program ran successfully, but produced no output"
 	}
 
+	fields := []*dgo.MessageEmbedField{
+		req.payloadField("Output", finalOutput, "output.txt"),
+		{Name: "Compilation in", Value: elapsedCompilation.String(), Inline: true},
+		{Name: "Execution in", Value: elapsedExecute.String(), Inline: true},
+		{Name: "Total", Value: (elapsedCompilation + elapsedExecute).String(), Inline: true},
+		{Name: "Cells used", Value: strconv.Itoa(out.MemoryCellsUsed), Inline: true},
+		{Name: "Instructions", Value: strconv.Itoa(out.InstructionsExecuted), Inline: true},
+	}
+
+	req.output = append([]byte{}, output...)
+
+	if req.BoolOption("dump") {
+		fields = append(fields, &dgo.MessageEmbedField{Name: "Memory", Value: memoryDump(p), Inline: false})
+	}
+
 	return &dgo.MessageEmbed{
 		Title:       "Execution successful",
 		Description: description,
 		Color:       SuccessColor,
-		Fields: []*dgo.MessageEmbedField{
-			{Name: "Output", Value: finalOutput, Inline: false},
-			{Name: "Compilation in", Value: elapsedCompilation.String(), Inline: true},
-			{Name: "Execution in", Value: elapsedExecute.String(), Inline: true},
-			{Name: "Total", Value: (elapsedCompilation + elapsedExecute).String(), Inline: true},
-			{Name: "Cells used", Value: strconv.Itoa(out.MemoryCellsUsed), Inline: true},
-			{Name: "Instructions", Value: strconv.Itoa(out.InstructionsExecuted), Inline: true},
-		},
-		Type: dgo.EmbedTypeArticle,
+		Fields:      fields,
+		Type:        dgo.EmbedTypeArticle,
 	}, nil
 }
+
+// memoryDump shows the memory cells used by a program that ran, by address,
+// as `address: value` pairs. Only the first maxDumpCells cells are shown.
+func memoryDump(p *bf.Program) string {
+	addrs := make([]int, 0, len(p.Memory))
+	for addr := range p.Memory {
+		addrs = append(addrs, addr)
+	}
+	sort.Ints(addrs)
+
+	var dump strings.Builder
+	dump.WriteString("```\n")
+	for i, addr := range addrs {
+		if i == maxDumpCells {
+			fmt.Fprintf(&dump, "… and %v more cells\n", len(addrs)-maxDumpCells)
+			break
+		}
+		fmt.Fprintf(&dump, "%v: %v\n", addr, p.Memory[addr])
+	}
+	if len(addrs) == 0 {
+		dump.WriteString("No memory used\n")
+	}
+	dump.WriteString("```")
+
+	return dump.String()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	bf "brainfuck-discord-bot/brainfuck"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Default time a program can run for, see setupLimits
+const defaultMaxTimeout = 30 * time.Second
+
+// execLimits are the highest limits the programs can run with. The guilds can lower them,
+// but not raise them.
+type execLimits struct {
+	MaxInstructions int
+	MaxMemory       int
+	Timeout         time.Duration
+}
+
+// Limits of the programs run by the bot, from the config file
+var execCeilings = execLimits{
+	MaxInstructions: bf.MaxExecInstructions,
+	MaxMemory:       bf.MaxMemory,
+	Timeout:         defaultMaxTimeout,
+}
+
+// setupExecCeilings reads the limits of the programs from the config
+func setupExecCeilings() {
+	execCeilings = execLimits{
+		MaxInstructions: viper.GetInt("limits.max_instructions"),
+		MaxMemory:       viper.GetInt("limits.max_memory"),
+		Timeout:         viper.GetDuration("limits.max_timeout"),
+	}
+}
+
+// execConfig returns the config the programs run with in a guild with the given settings, and
+// the time they can run for. Unset limits, and limits over the ceilings (which may have been
+// lowered since the guild set them), are replaced by the ceilings.
+func execConfig(g GuildSettings) (bf.Config, time.Duration) {
+	config := bf.Config{
+		MaxInstructions: capLimit(g.MaxInstructions, execCeilings.MaxInstructions),
+		MaxMemory:       capLimit(g.MaxMemory, execCeilings.MaxMemory),
+		CellWidth:       bf.Cell8,
+	}
+
+	if w, err := bf.ParseCellWidth(strconv.Itoa(g.CellWidth)); err == nil {
+		config.CellWidth = w
+	}
+	if mode, err := bf.ParseEOFMode(g.EOF); err == nil {
+		config.EOF = mode
+	}
+
+	timeout := execCeilings.Timeout
+	if t := time.Duration(g.TimeoutSeconds) * time.Second; t > 0 && t < timeout {
+		timeout = t
+	}
+
+	return config, timeout
+}
+
+// guildExecConfig returns the config the programs run with in a guild, and the time they can
+// run for. Direct messages use the ceilings and the default behavior.
+func guildExecConfig(guildID string) (bf.Config, time.Duration) {
+	if guildID == "" {
+		return execConfig(GuildSettings{})
+	}
+	return execConfig(settings.Get(guildID))
+}
+
+// capLimit returns the limit, or the ceiling if the limit is not set or is over it
+func capLimit(limit, ceiling int) int {
+	if limit <= 0 || limit > ceiling {
+		return ceiling
+	}
+	return limit
+}
+
+// parseLimit parses the value of a limit setting, which must be between 1 and the ceiling
+func parseLimit(value string, ceiling int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("expected a number, but got `%v`", value)
+	}
+	if n < 1 || n > ceiling {
+		return 0, fmt.Errorf("expected a number between 1 and %v, but got %v", ceiling, n)
+	}
+	return n, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+// fakeTransport is an in-memory Transport that records the messages sent by the bot
+type fakeTransport struct {
+	mu       sync.Mutex
+	nextID   int
+	messages []*dgo.Message
+	// Messages sent as the response of an interaction, by interaction ID
+	interactionReplies map[string]*dgo.Message
+	// Interactions acknowledged, by interaction ID
+	deferred map[string]bool
+	// Emojis the bot reacted with, by message ID
+	reactions map[string][]string
+	// Contents of the files attached to the messages, by message ID and file name
+	files map[string]map[string]string
+	// Permissions of the users in every channel, by user ID
+	permissions map[string]int64
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{
+		interactionReplies: make(map[string]*dgo.Message),
+		deferred:           make(map[string]bool),
+		reactions:          make(map[string][]string),
+		files:              make(map[string]map[string]string),
+		permissions:        make(map[string]int64),
+	}
+}
+
+func (f *fakeTransport) newMessage(channelID string, embed *dgo.MessageEmbed) *dgo.Message {
+	f.nextID++
+	msg := &dgo.Message{
+		ID:        fmt.Sprintf("reply-%v", f.nextID),
+		ChannelID: channelID,
+		Embeds:    []*dgo.MessageEmbed{embed},
+	}
+	f.messages = append(f.messages, msg)
+	return msg
+}
+
+func (f *fakeTransport) findMessage(messageID string) (*dgo.Message, error) {
+	for _, m := range f.messages {
+		if m.ID == messageID {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown message %v", messageID)
+}
+
+// attachFiles replaces the files of the message
+func (f *fakeTransport) attachFiles(msg *dgo.Message, files []*dgo.File) error {
+	contents := make(map[string]string)
+	for _, file := range files {
+		data, err := ioutil.ReadAll(file.Reader)
+		if err != nil {
+			return err
+		}
+		contents[file.Name] = string(data)
+	}
+	f.files[msg.ID] = contents
+	return nil
+}
+
+func (f *fakeTransport) ChannelMessageSendComplex(channelID string, data *dgo.MessageSend, options ...dgo.RequestOption) (*dgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	msg := f.newMessage(channelID, data.Embeds[0])
+	return msg, f.attachFiles(msg, data.Files)
+}
+
+func (f *fakeTransport) ChannelMessageEditComplex(m *dgo.MessageEdit, options ...dgo.RequestOption) (*dgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	msg, err := f.findMessage(m.ID)
+	if err != nil {
+		return nil, err
+	}
+	msg.Embeds = m.Embeds
+	return msg, f.attachFiles(msg, m.Files)
+}
+
+func (f *fakeTransport) ChannelMessageDelete(channelID, messageID string, options ...dgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, m := range f.messages {
+		if m.ID == messageID {
+			f.messages = append(f.messages[:i], f.messages[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown message %v", messageID)
+}
+
+func (f *fakeTransport) MessageReactionAdd(channelID, messageID, emojiID string, options ...dgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.reactions[messageID] = append(f.reactions[messageID], emojiID)
+	return nil
+}
+
+func (f *fakeTransport) MessageReactionRemove(channelID, messageID, emojiID, userID string, options ...dgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Only the reactions of the bot are recorded
+	if userID != "@me" {
+		return nil
+	}
+
+	emojis := f.reactions[messageID]
+	for i, e := range emojis {
+		if e == emojiID {
+			f.reactions[messageID] = append(emojis[:i], emojis[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeTransport) UserChannelPermissions(userID, channelID string, fetchOptions ...dgo.RequestOption) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.permissions[userID], nil
+}
+
+func (f *fakeTransport) InteractionRespond(interaction *dgo.Interaction, resp *dgo.InteractionResponse, options ...dgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deferred[interaction.ID] = true
+	return nil
+}
+
+func (f *fakeTransport) InteractionResponseEdit(interaction *dgo.Interaction, newresp *dgo.WebhookEdit, options ...dgo.RequestOption) (*dgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.deferred[interaction.ID] {
+		return nil, fmt.Errorf("interaction %v was not acknowledged", interaction.ID)
+	}
+
+	msg, ok := f.interactionReplies[interaction.ID]
+	if !ok {
+		msg = f.newMessage(interaction.ChannelID, nil)
+		f.interactionReplies[interaction.ID] = msg
+	}
+	msg.Embeds = *newresp.Embeds
+	return msg, f.attachFiles(msg, newresp.Files)
+}
+
+// Files returns the contents of the files attached to a message, by file name
+func (f *fakeTransport) Files(messageID string) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.files[messageID]
+}
+
+// Sent returns the messages sent by the bot, in the order they were sent
+func (f *fakeTransport) Sent() []*dgo.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]*dgo.Message(nil), f.messages...)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	dgo "github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+// isAdmin tells if the author of the request can manage the guild it was sent in
+func (req *Request) isAdmin() (bool, error) {
+	perms, err := req.Transport.UserChannelPermissions(req.Author.ID, req.ChannelID)
+	if err != nil {
+		return false, fmt.Errorf("could not get the permissions of %v: %v", req.Author.Username, err)
+	}
+	return perms&(dgo.PermissionAdministrator|dgo.PermissionManageServer) != 0, nil
+}
+
+// requireGuild returns the reply to a request sent in direct messages for a command that only
+// works in servers, along with its error, or a nil reply if the request was sent in a guild.
+// what names the things of the guild the command works with, like "The settings".
+func requireGuild(req *Request, what string) (*dgo.MessageEmbed, error) {
+	if req.GuildID != "" {
+		return nil, nil
+	}
+
+	err := fmt.Errorf("the %v command only works in servers", req.Name)
+	return &dgo.MessageEmbed{
+		Title:       "Not in a server",
+		Description: fmt.Sprintf("%v belong to servers, so the %v command only works in them.", what, req.Name),
+		Color:       ErrorColor,
+		Type:        dgo.EmbedTypeArticle,
+	}, err
+}
+
+// requireAdmin returns the "permission denied" reply, along with its error, if the author of the
+// request can not manage the guild, or a nil reply if they can.
+// action is what the request does, like "Changing the settings of the server".
+func requireAdmin(req *Request, action string) (*dgo.MessageEmbed, error) {
+	admin, err := req.isAdmin()
+	if err == nil && admin {
+		return nil, nil
+	}
+
+	if err == nil {
+		err = fmt.Errorf("%v does not have the Manage Server permission", req.Author.Username)
+	}
+	return permissionDeniedEmbed(action + " requires the Manage Server permission."), err
+}
+
+// permissionDeniedEmbed is the reply to the requests their author is not allowed to make
+func permissionDeniedEmbed(description string) *dgo.MessageEmbed {
+	return &dgo.MessageEmbed{
+		Title:       "Permission denied",
+		Description: description,
+		Color:       ErrorColor,
+		Type:        dgo.EmbedTypeArticle,
+	}
+}
+
+// settingsErrorEmbed is the reply to the commands whose settings could not be saved
+func settingsErrorEmbed() *dgo.MessageEmbed {
+	return &dgo.MessageEmbed{
+		Title:       "Settings error",
+		Description: "The settings could not be saved, please try again later.",
+		Color:       ErrorColor,
+		Type:        dgo.EmbedTypeArticle,
+	}
+}
+
+// databaseErrorEmbed is the reply to the commands that could not read or write the database
+func databaseErrorEmbed(err error) *dgo.MessageEmbed {
+	log.WithError(err).Error("database error")
+
+	return &dgo.MessageEmbed{
+		Title:       "Database error",
+		Description: "The data could not be read or saved, please try again later.",
+		Color:       ErrorColor,
+		Type:        dgo.EmbedTypeArticle,
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	transport := newFakeTransport()
+	transport.permissions["admin"] = dgo.PermissionManageServer
+
+	req := &Request{Transport: transport, Author: &dgo.User{ID: "admin"}}
+	if embed, err := requireAdmin(req, "Testing"); embed != nil || err != nil {
+		t.Errorf("requireAdmin() of an administrator = %v, %v, want no reply", embed, err)
+	}
+
+	req.Author = &dgo.User{ID: "member", Username: "member"}
+	embed, err := requireAdmin(req, "Testing")
+	if err == nil {
+		t.Errorf("requireAdmin() of a member returned no error")
+	}
+	checkEmbed(t, embed, "Permission denied", nil)
+	if want := "Testing requires the Manage Server permission."; embed.Description != want {
+		t.Errorf("requireAdmin() description = %q, want %q", embed.Description, want)
+	}
+}
+
+func TestRequireGuild(t *testing.T) {
+	req := &Request{Name: "config", GuildID: "guild"}
+	if embed, err := requireGuild(req, "The settings"); embed != nil || err != nil {
+		t.Errorf("requireGuild() in a guild = %v, %v, want no reply", embed, err)
+	}
+
+	req.GuildID = ""
+	embed, err := requireGuild(req, "The settings")
+	if err == nil {
+		t.Errorf("requireGuild() in direct messages returned no error")
+	}
+	checkEmbed(t, embed, "Not in a server", nil)
+	if want := "The settings belong to servers, so the config command only works in them."; embed.Description != want {
+		t.Errorf("requireGuild() description = %q, want %q", embed.Description, want)
+	}
+}
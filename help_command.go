@@ -1,24 +1,78 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+
 	dgo "github.com/bwmarrin/discordgo"
 )
 
-func helpCommand(args ...string) (*dgo.MessageEmbed, error) {
+var helpCmd = &Command{
+	Name:        "help",
+	Description: "Prints this message, or the help of a command",
+	Args:        argSchema{Min: 0, Max: 1},
+	Params: []Param{
+		{Name: "command", Description: "The command to get help for"},
+	},
+	Handler: helpCommand,
+}
+
+func helpCommand(req *Request) (*dgo.MessageEmbed, error) {
+	if len(req.Args) == 1 {
+		return commandHelp(req.Prefix, req.Args[0])
+	}
+
+	var list strings.Builder
+	for _, c := range commands.Commands() {
+		fmt.Fprintf(&list, "`%v %v` - %v", req.Prefix, c.Signature(), c.Description)
+		if len(c.Aliases) > 0 {
+			fmt.Fprintf(&list, ". Aliases: `%v`", strings.Join(c.Aliases, "`, `"))
+		}
+		list.WriteString("\n")
+	}
+
 	return &dgo.MessageEmbed{
 		Title: "Brainfuck Bot Help",
 		Fields: []*dgo.MessageEmbedField{
-			{Name: "Usage", Value: "`!bf <command> [arguments]`", Inline: false},
-			{
-				Name: "Available commands",
-				Value: "`!bf help` - Prints this message\n" +
-					"`!bf exec [input] <program>` - Executes a brainfuck program\n" +
-					"`!bf encode <target_output>` - Creates a Brainfuck program that outputs the characters in the target output\n" +
-					"`!bf shorten <program>` - Creates a shorter version of the program. Aliases: `short`",
-				Inline: false,
-			},
+			{Name: "Usage", Value: fmt.Sprintf("`%v <command> [arguments]`", req.Prefix), Inline: false},
+			req.pagedField("Available commands", list.String()),
+			{Name: "More help", Value: fmt.Sprintf("Type `%v help <command>` for the help of a command", req.Prefix), Inline: false},
 		},
 		Color: InfoColor,
 		Type:  dgo.EmbedTypeArticle,
 	}, nil
 }
+
+// commandHelp creates the help message of a single command
+func commandHelp(prefix, name string) (*dgo.MessageEmbed, error) {
+	c, ok := commands.Lookup(name)
+	if !ok {
+		err := fmt.Errorf("Command **%v** does not exist: type `%v help` to see the list of available commands", name, prefix)
+		return &dgo.MessageEmbed{
+			Title:       fmt.Sprintf("Command **%v** does not exist", name),
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	fields := []*dgo.MessageEmbedField{
+		{Name: "Usage", Value: fmt.Sprintf("`%v %v`", prefix, c.Signature()), Inline: false},
+	}
+
+	if len(c.Aliases) > 0 {
+		fields = append(fields, &dgo.MessageEmbedField{Name: "Aliases", Value: "`" + strings.Join(c.Aliases, "`, `") + "`", Inline: false})
+	}
+
+	if c.Details != "" {
+		fields = append(fields, &dgo.MessageEmbedField{Name: "Details", Value: c.Details, Inline: false})
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       fmt.Sprintf("Help for %v", c.Name),
+		Description: c.Description,
+		Fields:      fields,
+		Color:       InfoColor,
+		Type:        dgo.EmbedTypeArticle,
+	}, nil
+}
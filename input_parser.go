@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// parseInput parses the input given to the exec command into the values fed to the program.
+// The input is a list of items separated by commas and/or whitespace, where each item is
+// one of:
+//   - a decimal number, like 65 or -1
+//   - a hexadecimal number, like 0x41
+//   - a string in single or double quotes, like 'abc\n', fed to the program as its UTF-8 bytes.
+//     Strings support the escapes \n, \r, \t, \0, \\, \', \", \xHH (a single byte)
+//     and \uHHHH (a Unicode code point)
+func parseInput(input string) ([]int, error) {
+	var res []int
+
+	i := 0
+	for i < len(input) {
+		c := input[i]
+
+		switch {
+		case c == ',' || c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			str, end, err := parseInputString(input, i)
+			if err != nil {
+				return nil, err
+			}
+			for _, b := range str {
+				res = append(res, int(b))
+			}
+			i = end
+		default:
+			end := i
+			for end < len(input) && !strings.ContainsRune(", \t\n\r\"'", rune(input[end])) {
+				end++
+			}
+
+			token := input[i:end]
+			n, err := parseInputNumber(token)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input `%v` at position %v: %v", token, inputPosition(input, i), err)
+			}
+			res = append(res, n)
+			i = end
+		}
+	}
+
+	return res, nil
+}
+
+// parseInputNumber parses a decimal or hexadecimal (0x prefixed) number
+func parseInputNumber(token string) (int, error) {
+	lower := strings.ToLower(token)
+	digits := strings.TrimPrefix(lower, "-")
+	if strings.HasPrefix(digits, "0x") {
+		// The sign goes before the prefix, so the digits themselves can not have one
+		n, err := strconv.ParseUint(strings.TrimPrefix(digits, "0x"), 16, 63)
+		if err != nil {
+			return 0, fmt.Errorf("not a valid hexadecimal number")
+		}
+		if digits != lower {
+			return -int(n), nil
+		}
+		return int(n), nil
+	}
+
+	n, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, fmt.Errorf("expected a number like 65 or 0x41, or a quoted string like 'abc'")
+	}
+	return n, nil
+}
+
+// parseInputString parses the quoted string starting at position start of the input.
+// It returns the bytes the string represents and the position after the closing quote.
+func parseInputString(input string, start int) ([]byte, int, error) {
+	var res []byte
+	quote := input[start]
+
+	i := start + 1
+	for i < len(input) {
+		c := input[i]
+
+		switch c {
+		case quote:
+			return res, i + 1, nil
+		case '\\':
+			if i+1 >= len(input) {
+				return nil, 0, fmt.Errorf("unterminated escape sequence at position %v", inputPosition(input, i))
+			}
+
+			esc := input[i+1]
+			switch esc {
+			case 'n':
+				res = append(res, '\n')
+			case 'r':
+				res = append(res, '\r')
+			case 't':
+				res = append(res, '\t')
+			case '0':
+				res = append(res, 0)
+			case '\\', '\'', '"':
+				res = append(res, esc)
+			case 'x', 'u':
+				digits := 2
+				if esc == 'u' {
+					digits = 4
+				}
+				if i+2+digits > len(input) {
+					return nil, 0, fmt.Errorf("escape sequence `\\%c` at position %v needs %v hexadecimal digits", esc, inputPosition(input, i), digits)
+				}
+
+				n, err := strconv.ParseUint(input[i+2:i+2+digits], 16, 32)
+				if err != nil {
+					return nil, 0, fmt.Errorf("invalid escape sequence `%v` at position %v: expected %v hexadecimal digits", input[i:i+2+digits], inputPosition(input, i), digits)
+				}
+
+				if esc == 'x' {
+					res = append(res, byte(n))
+				} else if utf16.IsSurrogate(rune(n)) {
+					return nil, 0, fmt.Errorf("invalid escape sequence `%v` at position %v: surrogates are not code points", input[i:i+2+digits], inputPosition(input, i))
+				} else {
+					res = append(res, string(rune(n))...)
+				}
+				i += digits
+			default:
+				return nil, 0, fmt.Errorf("unknown escape sequence `\\%c` at position %v", esc, inputPosition(input, i))
+			}
+			i += 2
+		default:
+			res = append(res, c)
+			i++
+		}
+	}
+
+	return nil, 0, fmt.Errorf("string starting at position %v is missing the closing %c", inputPosition(input, start), quote)
+}
+
+// inputPosition converts a byte offset in the input to the 1-based position of the character
+// as seen by the user
+func inputPosition(input string, offset int) int {
+	return utf8.RuneCountInString(input[:offset]) + 1
+}
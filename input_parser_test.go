@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []int
+		wantErr string
+	}{
+		{name: "numbers", input: "67,68", want: []int{67, 68}},
+		{name: "negative and spaces", input: "-1, 2 3", want: []int{-1, 2, 3}},
+		{name: "hex", input: "0x41 0X42", want: []int{65, 66}},
+		{name: "double quoted string", input: `"ab\n"`, want: []int{'a', 'b', '\n'}},
+		{name: "single quoted string", input: `'a"b'`, want: []int{'a', '"', 'b'}},
+		{name: "escapes", input: `"\x41é\0\\"`, want: []int{0x41, 0xc3, 0xa9, 0, '\\'}},
+		{name: "mixed", input: `'hi',10,0x0`, want: []int{'h', 'i', 10, 0}},
+		{name: "empty", input: "", want: nil},
+		{name: "bad token", input: "1,abc", wantErr: "`abc` at position 3"},
+		{name: "bad hex", input: "0xzz", wantErr: "`0xzz` at position 1"},
+		{name: "unterminated string", input: `1 "abc`, wantErr: "position 3 is missing the closing"},
+		{name: "unknown escape", input: `"\q"`, wantErr: "`\\q` at position 2"},
+		{name: "short escape", input: `"\x4"`, wantErr: "position 2"},
+		{name: "negative hex", input: "-0x41", want: []int{-65}},
+		{name: "sign after the hex prefix", input: "0x-41", wantErr: "`0x-41` at position 1"},
+		{name: "surrogate escape", input: `"\uD800"`, wantErr: "`\\uD800` at position 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseInput(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseInput() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseInput() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseInput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	dgo "github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+// Name of the slash command option holding the attachment of commands that accept one
+const attachmentOptionName = "file"
+
+// Max length Discord allows for the descriptions of slash commands and their options
+const maxSlashDescription = 100
+
+// registerApplicationCommands registers every command of the bot as a slash command,
+// replacing the slash commands registered previously
+func registerApplicationCommands(s *dgo.Session) error {
+	var appCommands []*dgo.ApplicationCommand
+	for _, c := range commands.Commands() {
+		appCommands = append(appCommands, c.applicationCommand())
+	}
+
+	_, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, "", appCommands)
+	return err
+}
+
+// applicationCommand creates the definition of the slash command for the command
+func (c *Command) applicationCommand() *dgo.ApplicationCommand {
+	var options []*dgo.ApplicationCommandOption
+
+	// Discord requires the required options to come before the optional ones.
+	// A program can also be attached, so it is never required in the slash command.
+	for _, required := range []bool{true, false} {
+		for _, p := range c.Params {
+			slashRequired := p.Required && !(c.ProgramAttachment && p.Name == "program")
+			if slashRequired != required {
+				continue
+			}
+			options = append(options, &dgo.ApplicationCommandOption{
+				Type:        dgo.ApplicationCommandOptionString,
+				Name:        p.Name,
+				Description: slashDescription(p.Description),
+				Required:    slashRequired,
+			})
+		}
+	}
+
+	// Options named like a param (e.g. exec's --input) are the same slash option as the param
+	for i := range c.Options {
+		if c.param(c.Options[i].Name) == nil {
+			options = append(options, c.Options[i].applicationCommandOption())
+		}
+	}
+
+	if c.Attachment != "" {
+		options = append(options, &dgo.ApplicationCommandOption{
+			Type:        dgo.ApplicationCommandOptionAttachment,
+			Name:        attachmentOptionName,
+			Description: slashDescription(c.Attachment),
+		})
+	}
+
+	return &dgo.ApplicationCommand{
+		Name:        c.Name,
+		Description: slashDescription(c.Description),
+		Options:     options,
+	}
+}
+
+// applicationCommandOption creates the definition of the option in a slash command,
+// with the type and choices of the option so Discord validates it
+func (o *Option) applicationCommandOption() *dgo.ApplicationCommandOption {
+	opt := &dgo.ApplicationCommandOption{
+		Type:        dgo.ApplicationCommandOptionString,
+		Name:        o.Name,
+		Description: slashDescription(o.Description),
+	}
+
+	switch o.Type {
+	case IntOption:
+		opt.Type = dgo.ApplicationCommandOptionInteger
+		if o.Max != 0 {
+			min := float64(o.Min)
+			opt.MinValue = &min
+			opt.MaxValue = float64(o.Max)
+		}
+	case BoolOption:
+		opt.Type = dgo.ApplicationCommandOptionBoolean
+	default:
+		for _, choice := range o.Choices {
+			opt.Choices = append(opt.Choices, &dgo.ApplicationCommandOptionChoice{Name: choice, Value: choice})
+		}
+	}
+
+	return opt
+}
+
+// slashDescription shortens a description to the length allowed in slash commands
+func slashDescription(desc string) string {
+	runes := []rune(desc)
+	if len(runes) <= maxSlashDescription {
+		return desc
+	}
+	return string(runes[:maxSlashDescription-1]) + "…"
+}
+
+// newInteractionRequest creates the request for a slash command.
+// The options of the slash command are mapped back to the positional arguments and
+// options of the command, so both kinds of requests run the same way. Variadic params are
+// split into arguments like the text of a message, and the error of splitting them is returned
+// along with the request.
+func newInteractionRequest(t Transport, prefix string, i *dgo.Interaction) (*Request, error) {
+	data := i.ApplicationCommandData()
+
+	req := &Request{
+		Name:      data.Name,
+		Options:   make(map[string]string),
+		Transport: t,
+		Prefix:    guildPrefix(i.GuildID, prefix),
+		GuildID:   i.GuildID,
+		ChannelID: i.ChannelID,
+		Author:    i.User,
+	}
+
+	if i.Member != nil {
+		req.Author = i.Member.User
+		req.Roles = i.Member.Roles
+	}
+
+	values := make(map[string]*dgo.ApplicationCommandInteractionDataOption)
+	raw := []string{"/" + data.Name}
+	for _, opt := range data.Options {
+		values[opt.Name] = opt
+		raw = append(raw, fmt.Sprintf("%v:%v", opt.Name, opt.Value))
+	}
+	req.Raw = strings.Join(raw, " ")
+
+	c, ok := commands.Lookup(data.Name)
+	if !ok {
+		return req, nil
+	}
+
+	for _, p := range c.Params {
+		v, ok := values[p.Name]
+		if !ok {
+			continue
+		}
+		if !p.Variadic {
+			req.Args = append(req.Args, unwrapCodeBlock(v.StringValue()))
+			continue
+		}
+
+		args, err := ParseCommand(v.StringValue())
+		if err != nil {
+			return req, fmt.Errorf("invalid %v: %v", p.Name, err)
+		}
+		req.Args = append(req.Args, args...)
+	}
+
+	// Options are given as text, like in messages, and validated with the rest of the request
+	for _, o := range c.Options {
+		v, ok := values[o.Name]
+		if !ok || c.param(o.Name) != nil {
+			continue
+		}
+		// Discord sends the numbers as floats, which fmt would print in scientific notation
+		switch v.Type {
+		case dgo.ApplicationCommandOptionInteger:
+			req.Options[o.Name] = strconv.FormatInt(v.IntValue(), 10)
+		default:
+			req.Options[o.Name] = fmt.Sprint(v.Value)
+		}
+	}
+
+	if v, ok := values[attachmentOptionName]; ok && data.Resolved != nil {
+		if id, ok := v.Value.(string); ok {
+			if a, ok := data.Resolved.Attachments[id]; ok {
+				req.Attachments = append(req.Attachments, a)
+			}
+		}
+	}
+
+	return req, nil
+}
+
+// interactionHandler handles the slash commands of the bot
+func interactionHandler(s *dgo.Session, i *dgo.InteractionCreate) {
+	handleInteraction(s, bot_prefix, i.Interaction)
+}
+
+// handleInteraction runs a slash command, replying through the given transport
+func handleInteraction(t Transport, prefix string, i *dgo.Interaction) {
+	if i.Type != dgo.InteractionApplicationCommand {
+		return
+	}
+
+	responder := newInteractionResponder(t, i)
+	if err := responder.deferInteraction(); err != nil {
+		log.WithError(err).Warn("could not acknowledge slash command")
+		return
+	}
+
+	req, parseErr := newInteractionRequest(t, prefix, i)
+	req.Responder = responder
+
+	// Slash commands must be answered, so the ones in ignored channels get a short reply
+	if !answersCommand(req.Name, req.GuildID, req.ChannelID) {
+		err := responder.Send(&dgo.MessageEmbed{
+			Title:       "Not available here",
+			Description: "The bot does not answer in this channel.",
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		})
+		if err != nil {
+			log.WithError(err).Warn("could not answer a slash command in an ignored channel")
+		}
+		return
+	}
+
+	if parseErr != nil {
+		sendInvalidCommand(req, parseErr)
+		return
+	}
+
+	handleRequest(req)
+}
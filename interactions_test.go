@@ -0,0 +1,124 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+func TestApplicationCommand(t *testing.T) {
+	cmd := execCmd.applicationCommand()
+
+	var names []string
+	for _, o := range cmd.Options {
+		names = append(names, o.Name)
+	}
+
+	want := []string{"input", "program", "out", "limit", "cells", "dump", attachmentOptionName}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("applicationCommand() options = %v, want %v", names, want)
+	}
+
+	types := map[string]dgo.ApplicationCommandOptionType{
+		"out":   dgo.ApplicationCommandOptionString,
+		"limit": dgo.ApplicationCommandOptionInteger,
+		"dump":  dgo.ApplicationCommandOptionBoolean,
+	}
+	for _, o := range cmd.Options {
+		if want, ok := types[o.Name]; ok && o.Type != want {
+			t.Errorf("slash option %v has type %v, want %v", o.Name, o.Type, want)
+		}
+	}
+
+	for _, c := range commands.Commands() {
+		if got := len([]rune(c.applicationCommand().Description)); got > maxSlashDescription {
+			t.Errorf("slash command %v has a description with %v characters", c.Name, got)
+		}
+	}
+}
+
+func TestNewInteractionRequest(t *testing.T) {
+	i := &dgo.Interaction{
+		Type:   dgo.InteractionApplicationCommand,
+		Member: &dgo.Member{User: &dgo.User{ID: "42"}},
+		Data: dgo.ApplicationCommandInteractionData{
+			Name: "exec",
+			Options: []*dgo.ApplicationCommandInteractionDataOption{
+				{Name: "program", Type: dgo.ApplicationCommandOptionString, Value: ",."},
+				{Name: "out", Type: dgo.ApplicationCommandOptionString, Value: "hex"},
+				{Name: "input", Type: dgo.ApplicationCommandOptionString, Value: "65"},
+				{Name: "limit", Type: dgo.ApplicationCommandOptionInteger, Value: float64(100)},
+				{Name: "dump", Type: dgo.ApplicationCommandOptionBoolean, Value: true},
+			},
+		},
+	}
+
+	req, err := newInteractionRequest(nil, "!bf", i)
+	if err != nil {
+		t.Fatalf("newInteractionRequest() error = %v", err)
+	}
+
+	if want := []string{"65", ",."}; !reflect.DeepEqual(req.Args, want) {
+		t.Errorf("newInteractionRequest() args = %q, want %q", req.Args, want)
+	}
+	if want := map[string]string{"out": "hex", "limit": "100", "dump": "true"}; !reflect.DeepEqual(req.Options, want) {
+		t.Errorf("newInteractionRequest() options = %v, want %v", req.Options, want)
+	}
+	if req.Author.ID != "42" {
+		t.Errorf("newInteractionRequest() author = %v, want 42", req.Author.ID)
+	}
+
+	i.Data = dgo.ApplicationCommandInteractionData{
+		Name: "exec",
+		Options: []*dgo.ApplicationCommandInteractionDataOption{
+			{Name: "program", Type: dgo.ApplicationCommandOptionString, Value: "+"},
+			{Name: "limit", Type: dgo.ApplicationCommandOptionInteger, Value: float64(5000000)},
+		},
+	}
+	req, _ = newInteractionRequest(nil, "!bf", i)
+	if got := req.Options["limit"]; got != "5000000" {
+		t.Errorf("newInteractionRequest() limit = %v, want 5000000", got)
+	}
+}
+
+func TestSlashChallengeArguments(t *testing.T) {
+	transport := newFakeTransport()
+	transport.permissions["admin"] = dgo.PermissionManageServer
+
+	// send runs the challenge command as a slash command, returning its reply
+	send := func(id string, options ...*dgo.ApplicationCommandInteractionDataOption) *dgo.MessageEmbed {
+		t.Helper()
+
+		i := &dgo.Interaction{
+			ID:        id,
+			Type:      dgo.InteractionApplicationCommand,
+			GuildID:   "slash-golf-guild",
+			ChannelID: "channel",
+			Member:    &dgo.Member{User: &dgo.User{ID: "admin"}},
+			Data:      dgo.ApplicationCommandInteractionData{Name: "challenge", Options: options},
+		}
+		handleInteraction(transport, testPrefix, i)
+
+		reply, ok := transport.interactionReplies[id]
+		if !ok {
+			t.Fatalf("the interaction got no reply")
+		}
+		return reply.Embeds[0]
+	}
+	option := func(name, value string) *dgo.ApplicationCommandInteractionDataOption {
+		return &dgo.ApplicationCommandInteractionDataOption{Name: name, Type: dgo.ApplicationCommandOptionString, Value: value}
+	}
+
+	checkEmbed(t, send("create", option("action", "create"), option("name", "hello"), option("arguments", "Print   Hello")), "Challenge created", nil)
+	checkEmbed(t, send("add-case", option("action", "add-case"), option("name", "hello"), option("arguments", "'' 'Hello'")), "Test case added", nil)
+	checkEmbed(t, send("unclosed", option("action", "add-case"), option("name", "hello"), option("arguments", `'' "Hello`)), "Invalid command", nil)
+
+	c, err := challenges.Get("slash-golf-guild", "hello")
+	if err != nil {
+		t.Fatalf("the challenge was not stored: %v", err)
+	}
+	if c.Description != "Print Hello" || len(c.Cases) != 1 || string(c.Cases[0].Output) != "Hello" {
+		t.Errorf("slash challenge = %+v, want the description and test case of the arguments", c)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	bf "brainfuck-discord-bot/brainfuck"
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	dgo "github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+// Time a program runs before the "Running…" message is posted, and between its updates.
+// Discord allows 5 edits every 5 seconds per channel, so this leaves room for other commands.
+const liveUpdateInterval = 2 * time.Second
+
+// Max characters of the output shown while a program is running
+const livePreviewSize = 1000
+
+// Reaction users can add to the "Running…" message to stop the program
+const stopEmoji = "\u23f9\ufe0f"
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// Bytes returns a copy of the bytes written so far
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+// runLive runs the program with the input and config in opts, posting a "Running…" reply if the
+// program takes longer than liveUpdateInterval and updating it with the output produced so far at
+// every interval.
+// While the reply is up, the author of the command can stop the program with the stopEmoji
+// reaction, in which case context.Canceled is returned along with the output produced and the
+// instructions executed until then. Programs running for longer than timeout are stopped with
+// context.DeadlineExceeded.
+func runLive(req *Request, p *bf.Program, opts bf.RunOptions, timeout time.Duration, outMode bf.OutputMode) ([]byte, *bf.ExecutionResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var output syncBuffer
+	var instructions int64
+
+	type runResult struct {
+		res *bf.ExecutionResult
+		err error
+		// panic holds the panic of the program, with its stack, if it panicked
+		panic interface{}
+	}
+
+	done := make(chan runResult, 1)
+	go func() {
+		// Panics are raised again in the goroutine of the command, where they are recovered
+		defer func() {
+			if v := recover(); v != nil {
+				done <- runResult{panic: fmt.Sprintf("%v\n%s", v, debug.Stack())}
+			}
+		}()
+
+		opts.Output = &output
+		opts.Progress = func(pr bf.Progress) {
+			atomic.StoreInt64(&instructions, int64(pr.InstructionsExecuted))
+		}
+		res, err := p.RunContext(ctx, opts)
+		done <- runResult{res: res, err: err}
+	}()
+
+	ticker := time.NewTicker(liveUpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case r := <-done:
+			if msg := req.Responder.Reply(); msg != nil {
+				unwatchReactions(msg.ID, stopEmoji)
+				req.Transport.MessageReactionRemove(msg.ChannelID, msg.ID, stopEmoji, "@me")
+			}
+
+			if r.panic != nil {
+				panic(r.panic)
+			}
+
+			if r.err == context.Canceled {
+				r.res = &bf.ExecutionResult{InstructionsExecuted: int(atomic.LoadInt64(&instructions))}
+			}
+			return output.Bytes(), r.res, r.err
+		case <-ticker.C:
+			first := req.Responder.Reply() == nil
+
+			preview := bf.DecodeOutput(output.Bytes(), outMode)
+			err := req.Responder.Send(runningEmbed(preview, int(atomic.LoadInt64(&instructions))))
+			if err != nil {
+				log.WithError(err).Warn("could not update the running message")
+				continue
+			}
+
+			if first {
+				msg := req.Responder.Reply()
+				watchReactions(msg.ID, stopEmoji, func(r *dgo.MessageReaction) {
+					if r.UserID == req.Author.ID {
+						cancel()
+					}
+				})
+				req.Transport.MessageReactionAdd(msg.ChannelID, msg.ID, stopEmoji)
+			}
+		}
+	}
+}
+
+// runningEmbed creates the embed shown while a program is running
+func runningEmbed(output string, instructions int) *dgo.MessageEmbed {
+	if output == "" {
+		output = "No output yet"
+	}
+
+	runes := []rune(output)
+	if len(runes) > livePreviewSize {
+		output = "…" + string(runes[len(runes)-livePreviewSize:])
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Running…",
+		Description: "The program is still running. React with " + stopEmoji + " to stop it.",
+		Color:       InfoColor,
+		Fields: []*dgo.MessageEmbedField{
+			{Name: "Output so far", Value: output, Inline: false},
+			{Name: "Instructions", Value: strconv.Itoa(instructions), Inline: true},
+		},
+		Type: dgo.EmbedTypeArticle,
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
-	"strings"
 	"syscall"
 
 	log "github.com/sirupsen/logrus"
@@ -26,6 +26,9 @@ var (
 	bot_token  string
 )
 
+// ID of the user of the bot, to answer to the messages mentioning it
+var botUserID string
+
 func main() {
 	var err error
 
@@ -36,6 +39,26 @@ func main() {
 		return
 	}
 
+	setupLimits()
+	answerDirectMessages = viper.GetBool("direct_messages")
+	botOwners = viper.GetStringSlice("owners")
+
+	settings, err = loadSettingsStore(filepath.Join(viper.GetString("data_dir"), "guilds.json"))
+	if err != nil {
+		fmt.Printf("error loading guild settings: %v", err)
+		return
+	}
+
+	database, err := openDatabase(filepath.Join(viper.GetString("data_dir"), "bot.db"))
+	if err != nil {
+		fmt.Printf("error opening the database: %v", err)
+		return
+	}
+	defer database.Close()
+	snippets = &snippetStore{db: database}
+	shares = &shareStore{db: database}
+	challenges = &challengeStore{db: database}
+
 	// Setup logger
 	err = setupLogger()
 	defer func() {
@@ -59,6 +82,12 @@ func main() {
 		return
 	}
 
+	session.Identify.Intents = dgo.IntentGuildMessages |
+		dgo.IntentGuildMessageReactions |
+		dgo.IntentDirectMessages |
+		dgo.IntentDirectMessageReactions |
+		dgo.IntentMessageContent
+
 	err = session.Open()
 	if err != nil {
 		log.Printf("error opening connection to Discord, %s\n", err)
@@ -66,10 +95,21 @@ func main() {
 	}
 	defer session.Close()
 
+	botUserID = session.State.User.ID
+
 	log.Infof("Started brainfuck bot. Using prefix %v with token starting in %v", bot_prefix, bot_token[:4])
 
-	session.UpdateStatus(0, bot_prefix+" help")
+	session.UpdateGameStatus(0, bot_prefix+" help")
 	session.AddHandler(newMessageHandler)
+	session.AddHandler(messageUpdateHandler)
+	session.AddHandler(messageDeleteHandler)
+	session.AddHandler(reactionAddHandler)
+	session.AddHandler(interactionHandler)
+
+	err = registerApplicationCommands(session)
+	if err != nil {
+		log.Printf("error registering slash commands: %v", err)
+	}
 
 	// Wait for a CTRL-C or other control signal to terminate
 	sc := make(chan os.Signal, 1)
@@ -80,63 +120,174 @@ func main() {
 
 // newMessageHandler handles new messages received
 func newMessageHandler(s *dgo.Session, m *dgo.MessageCreate) {
+	handleMessage(s, bot_prefix, m.Message)
+}
 
-	if !strings.HasPrefix(m.Content, bot_prefix) {
+// messageUpdateHandler handles edited messages
+func messageUpdateHandler(s *dgo.Session, m *dgo.MessageUpdate) {
+	handleMessageEdit(s, bot_prefix, m.Message)
+}
+
+// handleMessage runs the command in a message if it starts with the prefix,
+// replying through the given transport
+func handleMessage(t Transport, prefix string, m *dgo.Message) {
+	responder := newMessageResponder(t, m)
+	runMessage(t, prefix, m, responder)
+
+	reply := responder.Reply()
+	if reply == nil {
 		return
 	}
 
-	args := ParseCommand(m.Content)
+	replies.Add(m.ID, reply)
 
-	// Check if the message is intended for this bot
-	if len(args) == 0 || args[0] != bot_prefix {
+	// The author of the command can delete the reply with a reaction
+	watchReactions(reply.ID, deleteEmoji, func(r *dgo.MessageReaction) {
+		if r.UserID == m.Author.ID {
+			handleMessageDelete(t, m)
+		}
+	})
+	t.MessageReactionAdd(reply.ChannelID, reply.ID, deleteEmoji)
+}
+
+// messageDeleteHandler handles deleted messages
+func messageDeleteHandler(s *dgo.Session, m *dgo.MessageDelete) {
+	handleMessageDelete(s, m.Message)
+}
+
+// handleMessageDelete deletes the reply to the command in a message, if the bot remembers it
+func handleMessageDelete(t Transport, m *dgo.Message) {
+	reply, ok := replies.Remove(m.ID)
+	if !ok {
+		return
+	}
+
+	unwatchReactions(reply.ID)
+	if err := t.ChannelMessageDelete(reply.ChannelID, reply.ID); err != nil {
+		log.WithError(err).Warn("could not delete a reply")
+	}
+}
+
+// handleMessageEdit runs again the command in an edited message, replacing the reply to the
+// original command. Only the messages with recent commands are run again, and the edits that
+// do not change the text of the message (like the previews of links) are ignored.
+// The reply is deleted if the message no longer has a command the bot answers.
+func handleMessageEdit(t Transport, prefix string, m *dgo.Message) {
+	if m.Author == nil || m.Content == "" {
+		return
+	}
+
+	reply, ok := replies.Get(m.ID)
+	if !ok {
 		return
 	}
 
-	if m.Content == bot_prefix {
+	// The pages of the previous reply are gone once it is replaced
+	stopPaginator(t, reply)
+	if !runMessage(t, prefix, m, editingMessageResponder(t, reply)) {
+		handleMessageDelete(t, m)
+	}
+}
+
+// runMessage runs the command in a message if it starts with the prefix of the guild (or the
+// default prefix if the guild has none) or a mention of the bot, replying with the given responder.
+// It returns false if the message has no command the bot answers.
+func runMessage(t Transport, prefix string, m *dgo.Message, responder *messageResponder) bool {
+	prefix = guildPrefix(m.GuildID, prefix)
+	calls := invocations(prefix)
+
+	if !startsWithAny(m.Content, calls) {
+		return false
+	}
+
+	args, parseErr := ParseCommand(m.Content)
+
+	// Check if the message is intended for this bot
+	if len(args) == 0 || !isInvocation(args[0], calls) {
+		return false
+	}
+
+	if len(args) == 1 {
 		// User called the bot but didn't specify a command,
 		// assume help command
-		args = []string{bot_prefix, "help"}
-	}
-
-	var outMessage *dgo.MessageEmbed
-	var err, sendErr error
-
-	switch args[1] {
-	case "help":
-		outMessage, err = helpCommand(args[1:]...)
-	case "exec":
-		outMessage, err = execCommand(args[1:]...)
-	case "encode":
-		outMessage, err = encodeCommand(args[1:]...)
-	case "shorten":
-		fallthrough
-	case "short":
-		outMessage, err = shortenCommand(args[1:]...)
-	default:
-		err = fmt.Errorf("Command **%v** does not exist: type `%v help` to see the list of available commands", args[1], bot_prefix)
-		outMessage = &dgo.MessageEmbed{
-			Title:       fmt.Sprintf("Command **%v** does not exist", args[1]),
-			Description: err.Error(),
+		args = []string{args[0], "help"}
+	}
+
+	// Ignored channels get no reply at all, not even for invalid commands
+	if !answersCommand(args[1], m.GuildID, m.ChannelID) {
+		return false
+	}
+
+	req := newRequest(args[1:])
+	req.Attachments = m.Attachments
+	req.Transport = t
+	req.Prefix = prefix
+	req.GuildID = m.GuildID
+	req.ChannelID = m.ChannelID
+	req.Author = m.Author
+	if m.Member != nil {
+		req.Roles = m.Member.Roles
+	}
+	req.Raw = m.Content
+	req.Responder = responder
+
+	if parseErr != nil {
+		sendInvalidCommand(req, parseErr)
+		return true
+	}
+
+	handleRequest(req)
+	return true
+}
+
+// sendInvalidCommand replies to a command that could not be parsed with the error.
+// Invalid commands count for the rate limits too, so they can not flood the channel.
+func sendInvalidCommand(req *Request, parseErr error) {
+	embed, err := checkRateLimit(req)
+	if err == nil {
+		embed = &dgo.MessageEmbed{
+			Title:       "Invalid command",
+			Description: parseErr.Error(),
 			Color:       ErrorColor,
 			Type:        dgo.EmbedTypeArticle,
 		}
 	}
-
-	_, sendErr = s.ChannelMessageSendEmbed(m.ChannelID, outMessage)
+	sendErr := req.Responder.Send(embed)
 
 	log.WithFields(log.Fields{
-		"guild":           m.GuildID,
-		"author_id":       m.Author.ID,
-		"author_username": m.Author.Username,
-		"raw_command":     m.Content,
-		"process_error":   err,
+		"guild":           req.GuildID,
+		"author_id":       req.Author.ID,
+		"author_username": req.Author.Username,
+		"raw_command":     req.Raw,
+		"process_error":   parseErr,
 		"send_error":      sendErr,
-		"out_title":       outMessage.Title,
-		"out_description": outMessage.Description,
-		"out_fields":      outMessage.Fields,
 	}).Info("command received")
 }
 
+// handleRequest runs a command and sends its reply.
+// The outcome of the command is logged by the logRequests middleware.
+func handleRequest(req *Request) {
+	// The commands recover their own panics (see recoverPanics), but replying can panic too
+	defer recoverReplyPanics(req)
+
+	outMessage, _ := dispatch(req)
+
+	err := req.Responder.Send(fitEmbed(outMessage), req.Files...)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"guild":       req.GuildID,
+			"author_id":   req.Author.ID,
+			"raw_command": req.Raw,
+			"send_error":  err,
+		}).Warn("could not send the reply to a command")
+		return
+	}
+
+	if req.paged != nil {
+		startPaginator(req, outMessage)
+	}
+}
+
 func setupLogger() error {
 	var err error
 
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	dgo "github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+// middleware wraps a command handler to run code around it, like logging or checks that may
+// reply without calling the next handler
+type middleware func(next commandHandler) commandHandler
+
+// chain wraps the handler with the middlewares, the first one being the outermost
+func chain(h commandHandler, middlewares ...middleware) commandHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// Middlewares every request goes through before running its command, in order
+var middlewares = []middleware{
+	logRequests,
+	recoverPanics,
+	rateLimit,
+	resolveCommand,
+	checkPermissions,
+	prepareArgs,
+	limitExecutions,
+}
+
+// dispatch runs the command invoked by the request through the middlewares
+func dispatch(req *Request) (*dgo.MessageEmbed, error) {
+	return chain(runCommand, middlewares...)(req)
+}
+
+// runCommand runs the handler of the command of the request, set by resolveCommand
+func runCommand(req *Request) (*dgo.MessageEmbed, error) {
+	return req.Command.Handler(req)
+}
+
+// logRequests logs every request with its outcome and the time it took
+func logRequests(next commandHandler) commandHandler {
+	return func(req *Request) (*dgo.MessageEmbed, error) {
+		start := time.Now()
+		embed, err := next(req)
+
+		log.WithFields(log.Fields{
+			"guild":           req.GuildID,
+			"author_id":       req.Author.ID,
+			"author_username": req.Author.Username,
+			"raw_command":     req.Raw,
+			"process_error":   err,
+			"duration":        time.Since(start).String(),
+			"out_title":       embed.Title,
+			"out_description": embed.Description,
+			"out_fields":      embed.Fields,
+		}).Info("command received")
+
+		return embed, err
+	}
+}
+
+// rateLimit replies with a "slow down" message to the requests over the rate limits
+func rateLimit(next commandHandler) commandHandler {
+	return func(req *Request) (*dgo.MessageEmbed, error) {
+		if embed, err := checkRateLimit(req); err != nil {
+			return embed, err
+		}
+		return next(req)
+	}
+}
+
+// resolveCommand finds the command invoked by the request, setting req.Command
+func resolveCommand(next commandHandler) commandHandler {
+	return func(req *Request) (*dgo.MessageEmbed, error) {
+		c, ok := commands.Lookup(req.Name)
+		if !ok {
+			err := fmt.Errorf("Command **%v** does not exist: type `%v help` to see the list of available commands", req.Name, req.Prefix)
+			return &dgo.MessageEmbed{
+				Title:       fmt.Sprintf("Command **%v** does not exist", req.Name),
+				Description: err.Error(),
+				Color:       ErrorColor,
+				Type:        dgo.EmbedTypeArticle,
+			}, err
+		}
+
+		req.Command = c
+		return next(req)
+	}
+}
+
+// prepareArgs takes the program of the request from its attachment, for the commands that
+// accept one, and validates the arguments and options of the request
+func prepareArgs(next commandHandler) commandHandler {
+	return func(req *Request) (*dgo.MessageEmbed, error) {
+		c := req.Command
+
+		if c.ProgramAttachment {
+			if err := extractProgramAttachment(req); err != nil {
+				return &dgo.MessageEmbed{
+					Title:       "Attachment error",
+					Description: err.Error(),
+					Color:       ErrorColor,
+					Type:        dgo.EmbedTypeArticle,
+				}, err
+			}
+		}
+
+		if err := c.validate(req); err != nil {
+			title := "Invalid arguments"
+			if _, ok := err.(*optionError); ok {
+				title = "Invalid option"
+			}
+
+			return &dgo.MessageEmbed{
+				Title:       title,
+				Description: err.Error() + fmt.Sprintf("\nType `%v help %v` for more information", req.Prefix, c.Name),
+				Color:       ErrorColor,
+				Fields: []*dgo.MessageEmbedField{
+					{Name: "Usage", Value: fmt.Sprintf("`%v %v`", req.Prefix, c.Signature()), Inline: false},
+				},
+				Type: dgo.EmbedTypeArticle,
+			}, err
+		}
+
+		return next(req)
+	}
+}
+
+// limitExecutions runs the commands that run programs in the execution workers,
+// replying that the bot is busy if too many are waiting
+func limitExecutions(next commandHandler) commandHandler {
+	return func(req *Request) (*dgo.MessageEmbed, error) {
+		if !req.Command.RunsPrograms {
+			return next(req)
+		}
+
+		var embed *dgo.MessageEmbed
+		var err error
+		if !executions.Do(func() { embed, err = next(req) }) {
+			err = fmt.Errorf("too many programs running")
+			return &dgo.MessageEmbed{
+				Title:       "Too many programs running",
+				Description: "The bot is busy running other programs. Try again in a moment.",
+				Color:       ErrorColor,
+				Type:        dgo.EmbedTypeArticle,
+			}, err
+		}
+		return embed, err
+	}
+}
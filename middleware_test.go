@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	dgo "github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	record := func(name string) middleware {
+		return func(next commandHandler) commandHandler {
+			return func(req *Request) (*dgo.MessageEmbed, error) {
+				calls = append(calls, name+" before")
+				embed, err := next(req)
+				calls = append(calls, name+" after")
+				return embed, err
+			}
+		}
+	}
+
+	h := chain(func(req *Request) (*dgo.MessageEmbed, error) {
+		calls = append(calls, "handler")
+		return &dgo.MessageEmbed{}, nil
+	}, record("outer"), record("inner"))
+	h(&Request{})
+
+	want := []string{"outer before", "inner before", "handler", "inner after", "outer after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
+
+// unreachable is a handler for middlewares that should not call the next handler
+func unreachable(t *testing.T) commandHandler {
+	return func(req *Request) (*dgo.MessageEmbed, error) {
+		t.Errorf("next handler called")
+		return &dgo.MessageEmbed{}, nil
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	req := &Request{Name: "SHORT", Prefix: testPrefix}
+	resolveCommand(func(req *Request) (*dgo.MessageEmbed, error) {
+		return &dgo.MessageEmbed{}, nil
+	})(req)
+	if req.Command != shortenCmd {
+		t.Errorf("resolveCommand() set the command %v, want shorten", req.Command)
+	}
+
+	embed, err := resolveCommand(unreachable(t))(&Request{Name: "nope", Prefix: testPrefix})
+	if err == nil {
+		t.Errorf("resolveCommand() of an unknown command returned no error")
+	}
+	checkEmbed(t, embed, "Command **nope** does not exist", nil)
+}
+
+func TestPrepareArgs(t *testing.T) {
+	req := newRequest([]string{"exec", "--limit=0", "+"})
+	req.Command = execCmd
+	req.Prefix = testPrefix
+	embed, _ := prepareArgs(unreachable(t))(req)
+	checkEmbed(t, embed, "Invalid option", map[string]string{"Usage": "`" + testPrefix + " " + execCmd.Signature() + "`"})
+}
+
+func TestLimitExecutions(t *testing.T) {
+	defer func(pool *workerPool) { executions = pool }(executions)
+
+	// A pool that is always full
+	executions = newWorkerPool(1, 0)
+	executions.admitted <- struct{}{}
+
+	embed, err := limitExecutions(unreachable(t))(&Request{Command: execCmd})
+	if err == nil {
+		t.Errorf("limitExecutions() with a full pool returned no error")
+	}
+	checkEmbed(t, embed, "Too many programs running", nil)
+
+	embed, _ = limitExecutions(func(req *Request) (*dgo.MessageEmbed, error) {
+		return &dgo.MessageEmbed{Title: "Help"}, nil
+	})(&Request{Command: helpCmd})
+	checkEmbed(t, embed, "Help", nil)
+}
+
+func TestLogRequests(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	wantErr := errors.New("failed")
+	logRequests(func(req *Request) (*dgo.MessageEmbed, error) {
+		return &dgo.MessageEmbed{Title: "Failed"}, wantErr
+	})(&Request{Raw: "!bf fail", Author: &dgo.User{ID: "author"}})
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatalf("logRequests() did not log the request")
+	}
+	if entry.Data["raw_command"] != "!bf fail" || entry.Data["process_error"] != wantErr || entry.Data["out_title"] != "Failed" {
+		t.Errorf("logRequests() logged %v", entry.Data)
+	}
+}
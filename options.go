@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OptionType is the type of the value of a command option
+type OptionType uint8
+
+const (
+	// StringOption takes any text, or one of the choices of the option if it has any
+	StringOption OptionType = iota
+	// IntOption takes an integer number
+	IntOption
+	// BoolOption is a flag, given as `--name` or `--name=true|false`
+	BoolOption
+)
+
+// Option describes a `--name=value` option of a command
+type Option struct {
+	Name        string
+	Description string
+	Type        OptionType
+	// Choices are the values allowed for a string option, if not empty
+	Choices []string
+	// Min and Max bound the values of an int option, if Max is not zero
+	Min int
+	Max int
+}
+
+// Usage returns how the option is written in the usage of a command, e.g. `[--out=<utf8|hex>]`
+func (o *Option) Usage() string {
+	switch {
+	case o.Type == BoolOption:
+		return "[--" + o.Name + "]"
+	case o.Type == IntOption:
+		return "[--" + o.Name + "=<n>]"
+	case len(o.Choices) > 0:
+		return "[--" + o.Name + "=<" + strings.Join(o.Choices, "|") + ">]"
+	default:
+		return "[--" + o.Name + "=<" + o.Name + ">]"
+	}
+}
+
+// parse checks the value given to the option, returning it converted to the type of the option
+func (o *Option) parse(value string) (interface{}, error) {
+	switch o.Type {
+	case BoolOption:
+		if value == "" {
+			return true, nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, &optionError{Option: o.Name, Reason: fmt.Sprintf("expected true or false, but got `%v`", value)}
+		}
+		return b, nil
+	case IntOption:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, &optionError{Option: o.Name, Reason: fmt.Sprintf("expected a number, but got `%v`", value)}
+		}
+		if o.Max != 0 && (n < o.Min || n > o.Max) {
+			return nil, &optionError{Option: o.Name, Reason: fmt.Sprintf("expected a number between %v and %v, but got %v", o.Min, o.Max, n)}
+		}
+		return n, nil
+	default:
+		if value == "" {
+			return nil, &optionError{Option: o.Name, Reason: "a value is required, like `--" + o.Name + "=<value>`"}
+		}
+		if len(o.Choices) == 0 {
+			return value, nil
+		}
+		for _, choice := range o.Choices {
+			if strings.EqualFold(value, choice) {
+				return choice, nil
+			}
+		}
+		return nil, &optionError{Option: o.Name, Reason: fmt.Sprintf("expected one of `%v`, but got `%v`", strings.Join(o.Choices, "`, `"), value)}
+	}
+}
+
+// optionError is a problem with an option given to a command
+type optionError struct {
+	Option string
+	Reason string
+}
+
+func (e *optionError) Error() string {
+	return fmt.Sprintf("invalid option `--%v`: %v", e.Option, e.Reason)
+}
+
+// splitOption splits an argument of the form `--name=value` or `--name` into its name and value.
+// Option names are made of lowercase letters and dashes and start with a letter, so Brainfuck
+// programs starting with `--` are not mistaken for options.
+func splitOption(arg string) (string, string, bool) {
+	if !strings.HasPrefix(arg, "--") {
+		return "", "", false
+	}
+
+	kv := strings.SplitN(arg[2:], "=", 2)
+	if kv[0] == "" || kv[0][0] < 'a' || kv[0][0] > 'z' {
+		return "", "", false
+	}
+
+	for _, c := range kv[0] {
+		if (c < 'a' || c > 'z') && c != '-' {
+			return "", "", false
+		}
+	}
+
+	if len(kv) == 1 {
+		return kv[0], "", true
+	}
+	return kv[0], kv[1], true
+}
+
+// StringOption returns the value of a string option, and whether it was given
+func (req *Request) StringOption(name string) (string, bool) {
+	v, ok := req.optionValues[name].(string)
+	return v, ok
+}
+
+// IntOption returns the value of an int option, or def if it was not given
+func (req *Request) IntOption(name string, def int) int {
+	if v, ok := req.optionValues[name].(int); ok {
+		return v
+	}
+	return def
+}
+
+// BoolOption tells if a flag was given and is not set to false
+func (req *Request) BoolOption(name string) bool {
+	v, _ := req.optionValues[name].(bool)
+	return v
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	dgo "github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reactions users add to a paginated reply to move to the previous and next pages
+const (
+	prevPageEmoji = "\u25c0\ufe0f"
+	nextPageEmoji = "\u25b6\ufe0f"
+)
+
+// Max characters in a page of a paged field, leaving room for code blocks and ellipses
+const pageSize = 1000
+
+// Max pages of a payload before it is sent as a file instead (see payloadField)
+const maxPages = 10
+
+// Time the pages of a reply can be navigated after it is sent
+var paginatorTimeout = 5 * time.Minute
+
+// pagedField is a field of a reply whose value is split in pages
+type pagedField struct {
+	field *dgo.MessageEmbedField
+	name  string
+	pages []string
+}
+
+// pagedField creates an embed field holding value, split in pages the author of the command
+// can move between with reactions. The field shows the first page, and the rest are shown by
+// the paginator started when the reply is sent. Only one field of a reply can be paged, so
+// values that need pages are truncated if another field already has them.
+func (req *Request) pagedField(name, value string) *dgo.MessageEmbedField {
+	pages := splitPages(value, pageSize)
+	if len(pages) == 1 || req.paged != nil {
+		return &dgo.MessageEmbedField{Name: name, Value: truncate(value, maxFieldValue), Inline: false}
+	}
+
+	req.paged = &pagedField{
+		field: &dgo.MessageEmbedField{Name: pageName(name, 0, len(pages)), Value: pages[0], Inline: false},
+		name:  name,
+		pages: pages,
+	}
+	return req.paged.field
+}
+
+// pageName is the name of a paged field showing the page with the given index
+func pageName(name string, page, pages int) string {
+	return fmt.Sprintf("%v (page %v/%v)", name, page+1, pages)
+}
+
+// splitPages splits text in pages of at most size characters.
+// Pages end at line breaks when possible, so listings keep their lines whole.
+func splitPages(text string, size int) []string {
+	var pages []string
+	for utf8.RuneCountInString(text) > size {
+		runes := []rune(text)
+		page := string(runes[:size])
+		if newline := strings.LastIndexByte(page, '\n'); newline > 0 {
+			page = page[:newline+1]
+		}
+		pages = append(pages, page)
+		text = text[len(page):]
+	}
+	return append(pages, text)
+}
+
+// paginator moves a sent reply between the pages of its paged field
+type paginator struct {
+	req   *Request
+	embed *dgo.MessageEmbed
+	paged *pagedField
+
+	mu      sync.Mutex
+	current int
+
+	// timer stops the paginator once paginatorTimeout passes
+	timer *time.Timer
+}
+
+// Paginators of the replies whose pages can be navigated, by the ID of the reply
+var paginators = struct {
+	sync.Mutex
+	byMessage map[string]*paginator
+}{byMessage: make(map[string]*paginator)}
+
+// startPaginator adds the page reactions to the reply of the request, and shows the page the
+// author of the command asks for with them until paginatorTimeout passes
+func startPaginator(req *Request, embed *dgo.MessageEmbed) {
+	msg := req.Responder.Reply()
+	if msg == nil || req.paged == nil {
+		return
+	}
+
+	p := &paginator{req: req, embed: embed, paged: req.paged}
+
+	watchReactions(msg.ID, prevPageEmoji, func(r *dgo.MessageReaction) { p.move(r, -1) })
+	watchReactions(msg.ID, nextPageEmoji, func(r *dgo.MessageReaction) { p.move(r, 1) })
+	req.Transport.MessageReactionAdd(msg.ChannelID, msg.ID, prevPageEmoji)
+	req.Transport.MessageReactionAdd(msg.ChannelID, msg.ID, nextPageEmoji)
+
+	paginators.Lock()
+	defer paginators.Unlock()
+
+	// A reply edited with a new command gets a new paginator, replacing the previous one
+	if old, ok := paginators.byMessage[msg.ID]; ok {
+		old.timer.Stop()
+	}
+	paginators.byMessage[msg.ID] = p
+	p.timer = time.AfterFunc(paginatorTimeout, func() {
+		paginators.Lock()
+		current := paginators.byMessage[msg.ID] == p
+		paginators.Unlock()
+
+		if current {
+			stopPaginator(req.Transport, msg)
+		}
+	})
+}
+
+// stopPaginator stops moving the reply between pages, and removes the page reactions of the bot
+func stopPaginator(t Transport, msg *dgo.Message) {
+	paginators.Lock()
+	if p, ok := paginators.byMessage[msg.ID]; ok {
+		p.timer.Stop()
+		delete(paginators.byMessage, msg.ID)
+	}
+	paginators.Unlock()
+
+	unwatchReactions(msg.ID, prevPageEmoji, nextPageEmoji)
+	t.MessageReactionRemove(msg.ChannelID, msg.ID, prevPageEmoji, "@me")
+	t.MessageReactionRemove(msg.ChannelID, msg.ID, nextPageEmoji, "@me")
+}
+
+// move shows the page step pages away from the current one, if the reaction asking for it
+// comes from the author of the command
+func (p *paginator) move(r *dgo.MessageReaction, step int) {
+	if r.UserID != p.req.Author.ID {
+		return
+	}
+
+	// Remove the reaction, so the same one can be used again to keep moving.
+	// This fails in DMs, where the author has to remove it themselves.
+	p.req.Transport.MessageReactionRemove(r.ChannelID, r.MessageID, r.Emoji.Name, r.UserID)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	page := p.current + step
+	if page < 0 || page >= len(p.paged.pages) {
+		return
+	}
+	p.current = page
+
+	p.paged.field.Name = pageName(p.paged.name, page, len(p.paged.pages))
+	p.paged.field.Value = p.paged.pages[page]
+
+	if err := p.req.Responder.Send(p.embed); err != nil {
+		log.WithError(err).Warn("could not change the page of a reply")
+	}
+}
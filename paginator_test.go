@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+func TestSplitPages(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "single page", text: "abc", want: []string{"abc"}},
+		{name: "cut at size", text: "abcdefg", want: []string{"abc", "def", "g"}},
+		{name: "cut at lines", text: "ab\ncd\nef", want: []string{"ab\n", "cd\n", "ef"}},
+		{name: "multibyte", text: "olá mundo", want: []string{"olá", " mu", "ndo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitPages(tt.text, 3); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitPages() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginator(t *testing.T) {
+	transport := newFakeTransport()
+	// Outputs 2000 'a's, which take two pages
+	handleMessage(transport, testPrefix, testMessage("!bf exec "+repeatProgram(20, 100)))
+
+	reply := transport.Sent()[0]
+	defer unwatchReactions(reply.ID)
+
+	page := strings.Repeat("a", pageSize)
+	checkEmbed(t, reply.Embeds[0], "Execution successful", map[string]string{"Output (page 1/2)": page})
+
+	if got, want := transport.reactions[reply.ID], []string{prevPageEmoji, nextPageEmoji, deleteEmoji}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reply reactions = %q, want %q", got, want)
+	}
+
+	react := func(userID, emoji string) {
+		handleReaction("bot", &dgo.MessageReaction{
+			UserID:    userID,
+			MessageID: reply.ID,
+			ChannelID: reply.ChannelID,
+			Emoji:     dgo.Emoji{Name: emoji},
+		})
+	}
+
+	react("someone else", nextPageEmoji)
+	checkEmbed(t, reply.Embeds[0], "Execution successful", map[string]string{"Output (page 1/2)": page})
+
+	react("author", prevPageEmoji)
+	checkEmbed(t, reply.Embeds[0], "Execution successful", map[string]string{"Output (page 1/2)": page})
+
+	react("author", nextPageEmoji)
+	checkEmbed(t, reply.Embeds[0], "Execution successful", map[string]string{"Output (page 2/2)": page})
+
+	react("author", nextPageEmoji)
+	checkEmbed(t, reply.Embeds[0], "Execution successful", map[string]string{"Output (page 2/2)": page})
+
+	if n := len(transport.Sent()); n != 1 {
+		t.Errorf("paginator sent %v messages, want the reply to be edited", n)
+	}
+}
+
+func TestPaginatorRestart(t *testing.T) {
+	defer func(timeout time.Duration) { paginatorTimeout = timeout }(paginatorTimeout)
+	paginatorTimeout = 300 * time.Millisecond
+
+	transport := newFakeTransport()
+	// Outputs 2000 'a's, which take two pages
+	msg := testMessage("!bf exec " + repeatProgram(20, 100))
+	msg.ID = "restarted-paginator"
+	handleMessage(transport, testPrefix, msg)
+
+	reply := transport.Sent()[0]
+	defer stopPaginator(transport, reply)
+
+	// The edit starts a new paginator on the same reply, outputting 'b's instead
+	time.Sleep(paginatorTimeout / 2)
+	edited := testMessage("!bf exec +" + repeatProgram(20, 100))
+	edited.ID = msg.ID
+	handleMessageEdit(transport, testPrefix, edited)
+
+	// The timer of the first paginator is over by now, but the new one still moves
+	time.Sleep(paginatorTimeout * 2 / 3)
+	handleReaction("bot", &dgo.MessageReaction{
+		UserID:    "author",
+		MessageID: reply.ID,
+		ChannelID: reply.ChannelID,
+		Emoji:     dgo.Emoji{Name: nextPageEmoji},
+	})
+	checkEmbed(t, reply.Embeds[0], "Execution successful", map[string]string{"Output (page 2/2)": strings.Repeat("b", pageSize)})
+
+	if got, want := transport.reactions[reply.ID], []string{deleteEmoji, prevPageEmoji, nextPageEmoji}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reply reactions = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+// Limits Discord puts on the length of the parts of an embed, in characters
+const (
+	maxFieldValue  = 1024
+	maxDescription = 4096
+	maxEmbedLength = 6000
+)
+
+// Number of characters of a payload sent as a file that are shown in the embed
+const payloadPreviewSize = 500
+
+// payloadField creates an embed field holding a payload, such as the output of a program.
+// Payloads that do not fit in an embed field are split in pages (see pagedField), and the ones
+// too long for maxPages pages, or that cannot be paged because another field already is, are
+// attached to the reply as a file with the given name, while the field only shows their beginning.
+func (req *Request) payloadField(name, value, filename string) *dgo.MessageEmbedField {
+	if fitsField(value) {
+		return &dgo.MessageEmbedField{Name: name, Value: value, Inline: false}
+	}
+
+	if req.paged == nil && len(splitPages(value, pageSize)) <= maxPages {
+		return req.pagedField(name, value)
+	}
+
+	req.Files = append(req.Files, &dgo.File{
+		Name:        filename,
+		ContentType: "text/plain; charset=utf-8",
+		Reader:      strings.NewReader(value),
+	})
+
+	return &dgo.MessageEmbedField{
+		Name:   fmt.Sprintf("%v (preview, see %v)", name, filename),
+		Value:  truncate(value, payloadPreviewSize),
+		Inline: false,
+	}
+}
+
+// fitsField tells if s fits in the value of an embed field
+func fitsField(s string) bool {
+	return utf8.RuneCountInString(s) <= maxFieldValue
+}
+
+// truncate shortens s to at most n characters, ending it with an ellipsis if it was cut
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// fitEmbed truncates the description and fields of the embed to the lengths Discord accepts,
+// so a reply with an unexpectedly long text is still sent instead of being rejected.
+// Fields are dropped from the end while the whole embed is too long.
+func fitEmbed(embed *dgo.MessageEmbed) *dgo.MessageEmbed {
+	embed.Description = truncate(embed.Description, maxDescription)
+	for _, f := range embed.Fields {
+		f.Value = truncate(f.Value, maxFieldValue)
+	}
+
+	for len(embed.Fields) > 0 && embedLength(embed) > maxEmbedLength {
+		embed.Fields = embed.Fields[:len(embed.Fields)-1]
+	}
+
+	return embed
+}
+
+// embedLength counts the characters of the embed that Discord limits to maxEmbedLength
+func embedLength(embed *dgo.MessageEmbed) int {
+	n := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Description)
+	for _, f := range embed.Fields {
+		n += utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+	}
+	if embed.Footer != nil {
+		n += utf8.RuneCountInString(embed.Footer.Text)
+	}
+	return n
+}
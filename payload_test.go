@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{s: "abc", n: 3, want: "abc"},
+		{s: "abcd", n: 3, want: "ab…"},
+		{s: "olá mundo", n: 4, want: "olá…"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %v) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFitEmbed(t *testing.T) {
+	embed := fitEmbed(&dgo.MessageEmbed{
+		Description: strings.Repeat("d", 5000),
+		Fields: []*dgo.MessageEmbedField{
+			{Name: "a", Value: strings.Repeat("a", 2000)},
+			{Name: "b", Value: strings.Repeat("b", 2000)},
+		},
+	})
+
+	if got := utf8.RuneCountInString(embed.Description); got != maxDescription {
+		t.Errorf("description has %v characters, want %v", got, maxDescription)
+	}
+	if len(embed.Fields) != 1 {
+		t.Fatalf("embed has %v fields, want 1", len(embed.Fields))
+	}
+	if got := utf8.RuneCountInString(embed.Fields[0].Value); got != maxFieldValue {
+		t.Errorf("field has %v characters, want %v", got, maxFieldValue)
+	}
+	if got := embedLength(embed); got > maxEmbedLength {
+		t.Errorf("embed has %v characters, want at most %v", got, maxEmbedLength)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	dgo "github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+// CommandRule restricts who can run a command in a guild and where.
+// Empty lists do not restrict anything, so the zero value allows everyone everywhere.
+type CommandRule struct {
+	// IDs of the roles allowed to run the command, if not empty
+	Roles []string `json:"roles,omitempty"`
+	// IDs of the channels the command can be run in, if not empty
+	Channels []string `json:"channels,omitempty"`
+}
+
+func (r CommandRule) clone() CommandRule {
+	r.Roles = append([]string(nil), r.Roles...)
+	r.Channels = append([]string(nil), r.Channels...)
+	return r
+}
+
+// empty tells if the rule does not restrict anything
+func (r CommandRule) empty() bool {
+	return len(r.Roles) == 0 && len(r.Channels) == 0
+}
+
+// allows tells if a member with the given roles can run the command in the channel
+func (r CommandRule) allows(roles []string, channelID string) bool {
+	if len(r.Channels) > 0 && !containsString(r.Channels, channelID) {
+		return false
+	}
+	if len(r.Roles) == 0 {
+		return true
+	}
+	for _, role := range roles {
+		if containsString(r.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// describe lists the roles and channels of the rule as mentions
+func (r CommandRule) describe() string {
+	if r.empty() {
+		return "Everyone, in every channel"
+	}
+
+	var lines []string
+	if len(r.Roles) > 0 {
+		lines = append(lines, "Roles: "+mentionAll("<@&%v>", r.Roles))
+	}
+	if len(r.Channels) > 0 {
+		lines = append(lines, "Channels: "+mentionAll("<#%v>", r.Channels))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mentionAll formats every ID with the format of its mention, like `<@&%v>` for roles
+func mentionAll(format string, ids []string) string {
+	mentions := make([]string, len(ids))
+	for i, id := range ids {
+		mentions[i] = fmt.Sprintf(format, id)
+	}
+	return strings.Join(mentions, ", ")
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns the values without s
+func removeString(values []string, s string) []string {
+	var res []string
+	for _, v := range values {
+		if v != s {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// checkPermissions replies with a "permission denied" message to the requests for commands the
+// rules of the guild do not allow for the author or in the channel.
+// The administrators of the guild can run every command, so they can not lock themselves out.
+func checkPermissions(next commandHandler) commandHandler {
+	return func(req *Request) (*dgo.MessageEmbed, error) {
+		if req.GuildID == "" {
+			return next(req)
+		}
+
+		rule := settings.Get(req.GuildID).Permissions[req.Command.Name]
+		if rule.allows(req.Roles, req.ChannelID) {
+			return next(req)
+		}
+
+		admin, err := req.isAdmin()
+		if err != nil {
+			log.WithError(err).Warn("could not check if the author of a command is an administrator")
+		}
+		if admin {
+			return next(req)
+		}
+
+		err = fmt.Errorf("%v is not allowed to run %v in channel %v", req.Author.Username, req.Command.Name, req.ChannelID)
+		embed := permissionDeniedEmbed(fmt.Sprintf("The `%v` command is restricted in this server.", req.Command.Name))
+		embed.Fields = []*dgo.MessageEmbedField{
+			{Name: "Allowed", Value: rule.describe(), Inline: false},
+		}
+		return embed, err
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+var permsCmd = &Command{
+	Name:        "perms",
+	Aliases:     []string{"permissions"},
+	Description: "Shows or changes who can run the commands and where",
+	Details: "Without arguments, lists the commands with restrictions in this server. With a command, shows who can run it.\n" +
+		"The actions are `add-role <role>`, `remove-role <role>`, `add-channel <channel>`, `remove-channel <channel>` and `reset`. " +
+		"A command with roles can only be run by members with one of them, and a command with channels only in those channels.\n" +
+		"Changing the permissions requires the Manage Server permission, and the administrators of the server can always run every command.",
+	Args: argSchema{Min: 0, Max: 3},
+	Params: []Param{
+		{Name: "command", Description: "The command to show or change the permissions of"},
+		{Name: "action", Description: "add-role, remove-role, add-channel, remove-channel or reset"},
+		{Name: "target", Description: "The role or channel, as a mention or an ID"},
+	},
+	Handler: permsCommand,
+}
+
+// permsAction changes the rule of a command with the target of the action
+type permsAction struct {
+	// Mention is the format of the mentions of the target, like `<@&%v>` for roles, or empty
+	// if the action takes no target
+	Mention string
+	Apply   func(rule *CommandRule, id string)
+}
+
+var permsActions = map[string]permsAction{
+	"add-role": {Mention: "<@&%v>", Apply: func(rule *CommandRule, id string) {
+		if !containsString(rule.Roles, id) {
+			rule.Roles = append(rule.Roles, id)
+		}
+	}},
+	"remove-role": {Mention: "<@&%v>", Apply: func(rule *CommandRule, id string) {
+		rule.Roles = removeString(rule.Roles, id)
+	}},
+	"add-channel": {Mention: "<#%v>", Apply: func(rule *CommandRule, id string) {
+		if !containsString(rule.Channels, id) {
+			rule.Channels = append(rule.Channels, id)
+		}
+	}},
+	"remove-channel": {Mention: "<#%v>", Apply: func(rule *CommandRule, id string) {
+		rule.Channels = removeString(rule.Channels, id)
+	}},
+	"reset": {Apply: func(rule *CommandRule, id string) {
+		*rule = CommandRule{}
+	}},
+}
+
+// parseMention returns the ID in a mention with the given format, like `<@&%v>`, or the
+// argument itself if it is already an ID
+func parseMention(arg, format string) (string, error) {
+	prefix := strings.SplitN(format, "%v", 2)[0]
+	id := strings.TrimSuffix(strings.TrimPrefix(arg, prefix), ">")
+	if id == "" || strings.Trim(id, "0123456789") != "" {
+		return "", fmt.Errorf("`%v` is not a mention or an ID", arg)
+	}
+	return id, nil
+}
+
+func permsCommand(req *Request) (*dgo.MessageEmbed, error) {
+	if embed, err := requireGuild(req, "The permissions"); embed != nil {
+		return embed, err
+	}
+
+	rules := settings.Get(req.GuildID).Permissions
+	if len(req.Args) == 0 {
+		return listPermissions(req, rules), nil
+	}
+
+	c, ok := commands.Lookup(req.Args[0])
+	if !ok {
+		err := fmt.Errorf("there is no command named `%v`", req.Args[0])
+		return &dgo.MessageEmbed{
+			Title:       "Unknown command",
+			Description: err.Error() + fmt.Sprintf("\nType `%v help` to see the list of available commands", req.Prefix),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	if len(req.Args) == 1 {
+		return permissionsEmbed("Permissions of "+c.Name, "", rules[c.Name]), nil
+	}
+
+	action, ok := permsActions[strings.ToLower(req.Args[1])]
+	var id string
+	var err error
+	switch {
+	case !ok:
+		err = fmt.Errorf("unknown action `%v`", req.Args[1])
+	case action.Mention != "" && len(req.Args) < 3:
+		err = fmt.Errorf("%v needs a role or channel", req.Args[1])
+	case action.Mention == "" && len(req.Args) > 2:
+		err = fmt.Errorf("%v does not take a role or channel", req.Args[1])
+	case action.Mention != "":
+		id, err = parseMention(req.Args[2], action.Mention)
+	}
+	if err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Invalid arguments",
+			Description: err.Error() + fmt.Sprintf("\nType `%v help perms` for more information", req.Prefix),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	if embed, err := requireAdmin(req, "Changing the permissions of the commands"); embed != nil {
+		return embed, err
+	}
+
+	err = settings.Update(req.GuildID, func(g *GuildSettings) error {
+		rule := g.Permissions[c.Name]
+		action.Apply(&rule, id)
+
+		if rule.empty() {
+			delete(g.Permissions, c.Name)
+			return nil
+		}
+		if g.Permissions == nil {
+			g.Permissions = make(map[string]CommandRule)
+		}
+		g.Permissions[c.Name] = rule
+		return nil
+	})
+	if err != nil {
+		return settingsErrorEmbed(), err
+	}
+
+	embed := permissionsEmbed("Permissions changed", fmt.Sprintf("The permissions of %v were changed.", c.Name),
+		settings.Get(req.GuildID).Permissions[c.Name])
+	embed.Color = SuccessColor
+	return embed, nil
+}
+
+// permissionsEmbed shows who can run a command and where
+func permissionsEmbed(title, description string, rule CommandRule) *dgo.MessageEmbed {
+	return &dgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       InfoColor,
+		Fields: []*dgo.MessageEmbedField{
+			{Name: "Allowed", Value: rule.describe(), Inline: false},
+		},
+		Type: dgo.EmbedTypeArticle,
+	}
+}
+
+// listPermissions lists the commands with restrictions in the guild
+func listPermissions(req *Request, rules map[string]CommandRule) *dgo.MessageEmbed {
+	embed := &dgo.MessageEmbed{
+		Title:       "Permissions",
+		Description: "Every command can be run by everyone, in every channel.",
+		Color:       InfoColor,
+		Type:        dgo.EmbedTypeArticle,
+	}
+
+	for _, c := range commands.Commands() {
+		if rule, ok := rules[c.Name]; ok {
+			embed.Fields = append(embed.Fields, &dgo.MessageEmbedField{Name: c.Name, Value: rule.describe(), Inline: false})
+		}
+	}
+	if len(embed.Fields) > 0 {
+		embed.Description = fmt.Sprintf("The other commands can be run by everyone, in every channel. Type `%v help perms` to change them.", req.Prefix)
+	}
+	return embed
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+func TestParseMention(t *testing.T) {
+	tests := []struct {
+		arg     string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{arg: "<@&123>", format: "<@&%v>", want: "123"},
+		{arg: "123", format: "<@&%v>", want: "123"},
+		{arg: "<#456>", format: "<#%v>", want: "456"},
+		{arg: "<#456>", format: "<@&%v>", wantErr: true},
+		{arg: "general", format: "<#%v>", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseMention(tt.arg, tt.format)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseMention(%q, %q) = %q, %v, want %q, wantErr %v", tt.arg, tt.format, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	transport := newFakeTransport()
+	transport.permissions["admin"] = dgo.PermissionManageServer
+
+	send := func(content, authorID, channelID string, roles ...string) *dgo.MessageEmbed {
+		t.Helper()
+
+		msg := testMessage(content)
+		msg.GuildID = "perms-guild"
+		msg.ChannelID = channelID
+		msg.Author.ID = authorID
+		msg.Member = &dgo.Member{Roles: roles}
+
+		handleMessage(transport, testPrefix, msg)
+		sent := transport.Sent()
+		return sent[len(sent)-1].Embeds[0]
+	}
+
+	checkEmbed(t, send("!bf perms shorten add-role <@&42>", "author", "channel"), "Permission denied", nil)
+	checkEmbed(t, send("!bf perms shorten add-role everyone", "admin", "channel"), "Invalid arguments", nil)
+	checkEmbed(t, send("!bf perms short add-role <@&42>", "admin", "channel"), "Permissions changed", map[string]string{"Allowed": "Roles: <@&42>"})
+	checkEmbed(t, send("!bf perms shorten add-channel <#esolangs>", "admin", "channel"), "Invalid arguments", nil)
+	checkEmbed(t, send("!bf perms shorten add-channel <#7>", "admin", "channel"), "Permissions changed", map[string]string{"Allowed": "Roles: <@&42>\nChannels: <#7>"})
+
+	checkEmbed(t, send("!bf shorten +-", "author", "7"), "Permission denied", map[string]string{"Allowed": "Roles: <@&42>\nChannels: <#7>"})
+	checkEmbed(t, send("!bf shorten +-", "author", "channel", "42"), "Permission denied", nil)
+	checkEmbed(t, send("!bf shorten +-", "author", "7", "1", "42"), "", nil)
+	checkEmbed(t, send("!bf shorten +-", "admin", "channel"), "", nil)
+	checkEmbed(t, send("!bf exec +", "author", "channel"), "Execution successful", nil)
+
+	checkEmbed(t, send("!bf perms", "author", "channel"), "Permissions", map[string]string{"shorten": "Roles: <@&42>\nChannels: <#7>"})
+	checkEmbed(t, send("!bf perms shorten reset", "admin", "channel"), "Permissions changed", map[string]string{"Allowed": "Everyone, in every channel"})
+	checkEmbed(t, send("!bf shorten +-", "author", "channel"), "", nil)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Max length of the prefix of a guild
+const maxPrefixLength = 10
+
+// guildPrefix returns the prefix the bot answers to in a guild, or the default prefix if the
+// guild did not set one
+func guildPrefix(guildID, defaultPrefix string) string {
+	if guildID == "" {
+		return defaultPrefix
+	}
+	if p := settings.Get(guildID).Prefix; p != "" {
+		return p
+	}
+	return defaultPrefix
+}
+
+// invocations returns the ways to call the bot: with the prefix, or by mentioning the bot,
+// which always works in case the prefix is forgotten or clashes with another bot
+func invocations(prefix string) []string {
+	calls := []string{prefix}
+	if botUserID != "" {
+		calls = append(calls, "<@"+botUserID+">", "<@!"+botUserID+">")
+	}
+	return calls
+}
+
+// startsWithAny tells if s starts with any of the prefixes
+func startsWithAny(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInvocation tells if the first argument of a message is one of the ways to call the bot
+func isInvocation(arg string, calls []string) bool {
+	for _, c := range calls {
+		if arg == c {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePrefix checks that a prefix can be typed as the first argument of a command
+func validatePrefix(prefix string) error {
+	if prefix == "" || len([]rune(prefix)) > maxPrefixLength {
+		return fmt.Errorf("the prefix must have between 1 and %v characters", maxPrefixLength)
+	}
+	for _, c := range prefix {
+		if unicode.IsSpace(c) || strings.ContainsRune("'\"`\\", c) {
+			return fmt.Errorf("the prefix can not have spaces, quotes, backticks or backslashes")
+		}
+	}
+	if strings.HasPrefix(prefix, "<@") || strings.HasPrefix(prefix, "/") {
+		return fmt.Errorf("the prefix can not look like a mention or a slash command")
+	}
+	return nil
+}
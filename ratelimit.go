@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+// Number of buckets a rateLimiter holds before forgetting the ones of idle users
+const maxRateBuckets = 10_000
+
+// tokenBucket allows bursts of up to capacity requests, refilling at a steady rate
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter limits the requests of each key (like a user or a guild) with a token bucket
+type rateLimiter struct {
+	// capacity is the max number of requests allowed in a burst
+	capacity float64
+	// rate is the number of requests allowed per second after a burst
+	rate float64
+	now  func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// newRateLimiter creates a limiter that allows bursts of burst requests per key,
+// and perMinute requests per minute after them
+func newRateLimiter(burst int, perMinute float64) *rateLimiter {
+	return &rateLimiter{
+		capacity: float64(burst),
+		rate:     perMinute / 60,
+		now:      time.Now,
+		buckets:  make(map[string]*tokenBucket),
+	}
+}
+
+// Allow takes a token from the bucket of the key, telling if the request is allowed.
+// If it is not, it also returns the time until the next request of the key is allowed.
+func (l *rateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxRateBuckets {
+			l.prune(now)
+		}
+		b = &tokenBucket{tokens: l.capacity, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.capacity {
+		b.tokens = l.capacity
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// prune forgets the buckets that are full again, since they behave like new ones
+func (l *rateLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Default limits of the commands of each user and guild, see setupLimits
+const (
+	defaultUserBurst      = 5
+	defaultUserPerMinute  = 10
+	defaultGuildBurst     = 20
+	defaultGuildPerMinute = 60
+)
+
+// Limiters of the commands of each user and of each guild
+var (
+	userLimiter  = newRateLimiter(defaultUserBurst, defaultUserPerMinute)
+	guildLimiter = newRateLimiter(defaultGuildBurst, defaultGuildPerMinute)
+)
+
+// checkRateLimit takes a token from the buckets of the author and guild of the request,
+// returning the embed to reply with if either of them is empty.
+// Commands in DMs are only limited per user.
+func checkRateLimit(req *Request) (*dgo.MessageEmbed, error) {
+	ok, wait := userLimiter.Allow(req.Author.ID)
+	if ok && req.GuildID != "" {
+		ok, wait = guildLimiter.Allow(req.GuildID)
+	}
+	if ok {
+		return nil, nil
+	}
+
+	wait = wait.Round(time.Second)
+	if wait < time.Second {
+		wait = time.Second
+	}
+
+	err := fmt.Errorf("rate limit reached, next command allowed in %v", wait)
+	return &dgo.MessageEmbed{
+		Title:       "Slow down",
+		Description: fmt.Sprintf("Too many commands were sent in a short time. Try again in %v.", wait),
+		Color:       ErrorColor,
+		Type:        dgo.EmbedTypeArticle,
+	}, err
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(2, 30)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("user"); !ok {
+			t.Fatalf("request %v of the burst was not allowed", i+1)
+		}
+	}
+
+	ok, wait := l.Allow("user")
+	if ok {
+		t.Fatalf("request after the burst was allowed")
+	}
+	if wait != 2*time.Second {
+		t.Errorf("wait after the burst = %v, want 2s", wait)
+	}
+
+	if ok, _ := l.Allow("other user"); !ok {
+		t.Errorf("request of another user was not allowed")
+	}
+
+	now = now.Add(2 * time.Second)
+	if ok, _ := l.Allow("user"); !ok {
+		t.Errorf("request after waiting was not allowed")
+	}
+	if ok, _ := l.Allow("user"); ok {
+		t.Errorf("second request after waiting for one token was allowed")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	defer func(user, guild *rateLimiter) { userLimiter, guildLimiter = user, guild }(userLimiter, guildLimiter)
+	userLimiter = newRateLimiter(1, 1)
+	guildLimiter = newRateLimiter(1_000_000, 60)
+
+	transport := newFakeTransport()
+	handleMessage(transport, testPrefix, testMessage("!bf help"))
+	handleMessage(transport, testPrefix, testMessage("!bf help"))
+
+	sent := transport.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 replies, but got %v", len(sent))
+	}
+	checkEmbed(t, sent[0].Embeds[0], "Brainfuck Bot Help", nil)
+	checkEmbed(t, sent[1].Embeds[0], "Slow down", nil)
+
+	// Invalid commands are limited too
+	handleMessage(transport, testPrefix, testMessage(`!bf exec "abc`))
+	if sent = transport.Sent(); len(sent) != 3 {
+		t.Fatalf("expected 3 replies, but got %v", len(sent))
+	}
+	checkEmbed(t, sent[2].Embeds[0], "Slow down", nil)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+// Reaction the author of a command can add to the reply to delete it
+const deleteEmoji = "\U0001f5d1\ufe0f"
+
+// reactionHandler handles a reaction added to a message the bot is watching
+type reactionHandler func(r *dgo.MessageReaction)
+
+// Handlers of the reactions added to the messages being watched, by message ID and emoji
+var reactionHandlers = struct {
+	sync.Mutex
+	byMessage map[string]map[string]reactionHandler
+}{byMessage: make(map[string]map[string]reactionHandler)}
+
+// watchReactions calls h for every reaction with the given emoji added to the message with
+// the given ID, until unwatchReactions is called for that message and emoji
+func watchReactions(messageID, emoji string, h reactionHandler) {
+	reactionHandlers.Lock()
+	defer reactionHandlers.Unlock()
+
+	handlers, ok := reactionHandlers.byMessage[messageID]
+	if !ok {
+		handlers = make(map[string]reactionHandler)
+		reactionHandlers.byMessage[messageID] = handlers
+	}
+	handlers[emojiKey(emoji)] = h
+}
+
+// unwatchReactions stops watching the reactions with the given emojis of the message with the
+// given ID, or all of its reactions if no emoji is given
+func unwatchReactions(messageID string, emojis ...string) {
+	reactionHandlers.Lock()
+	defer reactionHandlers.Unlock()
+
+	handlers := reactionHandlers.byMessage[messageID]
+	for _, emoji := range emojis {
+		delete(handlers, emojiKey(emoji))
+	}
+
+	if len(emojis) == 0 || len(handlers) == 0 {
+		delete(reactionHandlers.byMessage, messageID)
+	}
+}
+
+// reactionAddHandler dispatches the reactions added to watched messages to their handlers
+func reactionAddHandler(s *dgo.Session, r *dgo.MessageReactionAdd) {
+	handleReaction(s.State.User.ID, r.MessageReaction)
+}
+
+// handleReaction calls the handler of the reaction added to a message, if it is watched,
+// ignoring the reactions of the bot itself
+func handleReaction(botID string, r *dgo.MessageReaction) {
+	if r.UserID == botID {
+		return
+	}
+
+	reactionHandlers.Lock()
+	h, ok := reactionHandlers.byMessage[r.MessageID][emojiKey(r.Emoji.Name)]
+	reactionHandlers.Unlock()
+
+	if ok {
+		h(r)
+	}
+}
+
+// emojiKey identifies an emoji regardless of the emoji variation selector,
+// which clients may or may not add to the reactions
+func emojiKey(emoji string) string {
+	return strings.TrimSuffix(emoji, "\ufe0f")
+}
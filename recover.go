@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+
+	dgo "github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+// Number of panics recovered from commands since the bot started
+var panicsRecovered int64
+
+// recoverPanics wraps a command handler so a panic in it replies with an internal error instead
+// of stopping the bot. The panic is logged with its stack trace and a correlation ID that is
+// also shown to the user, so their report can be matched with the logs.
+func recoverPanics(next commandHandler) commandHandler {
+	return func(req *Request) (embed *dgo.MessageEmbed, err error) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+
+			id := logPanic(req, v, "command panicked")
+			err = fmt.Errorf("command panicked: %v", v)
+			embed = &dgo.MessageEmbed{
+				Title:       "Internal error",
+				Description: fmt.Sprintf("Something went wrong running the command. If it keeps happening, please report it with the error ID `%v`.", id),
+				Color:       ErrorColor,
+				Type:        dgo.EmbedTypeArticle,
+			}
+		}()
+
+		return next(req)
+	}
+}
+
+// recoverReplyPanics recovers from a panic while sending the reply to a request, after its
+// handler returned, so it does not stop the bot. It must be deferred, and only logs the panic,
+// since the reply is what failed.
+func recoverReplyPanics(req *Request) {
+	if v := recover(); v != nil {
+		logPanic(req, v, "reply panicked")
+	}
+}
+
+// logPanic logs a recovered panic with its stack trace, returning the correlation ID of the log
+func logPanic(req *Request, v interface{}, msg string) string {
+	id := correlationID()
+	count := atomic.AddInt64(&panicsRecovered, 1)
+
+	log.WithFields(log.Fields{
+		"correlation_id": id,
+		"raw_command":    req.Raw,
+		"panic":          fmt.Sprint(v),
+		"stack":          string(debug.Stack()),
+		"panics":         count,
+	}).Error(msg)
+	return id
+}
+
+// correlationID creates a random ID to find the logs of an error
+func correlationID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
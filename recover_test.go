@@ -0,0 +1,83 @@
+package main
+
+import (
+	bf "brainfuck-discord-bot/brainfuck"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+func TestRecoverPanics(t *testing.T) {
+	before := atomic.LoadInt64(&panicsRecovered)
+
+	embed, err := recoverPanics(func(req *Request) (*dgo.MessageEmbed, error) {
+		panic("boom")
+	})(&Request{Raw: "!bf boom"})
+
+	if err == nil {
+		t.Errorf("recovered handler returned no error")
+	}
+	checkEmbed(t, embed, "Internal error", nil)
+	if !strings.Contains(embed.Description, "error ID `") {
+		t.Errorf("internal error embed does not show the correlation ID: %v", embed.Description)
+	}
+	if got := atomic.LoadInt64(&panicsRecovered); got != before+1 {
+		t.Errorf("panicsRecovered = %v, want %v", got, before+1)
+	}
+}
+
+func TestRecoverPanicsOfPrograms(t *testing.T) {
+	transport := newFakeTransport()
+	msg := testMessage("!bf exec ,")
+	req := &Request{
+		Transport: transport,
+		Author:    msg.Author,
+		Responder: newMessageResponder(transport, msg),
+	}
+
+	p, err := bf.Compile(",")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	input := bf.InputProviderFunc(func() (int, error) { panic("input panicked") })
+
+	embed, err := recoverPanics(func(req *Request) (*dgo.MessageEmbed, error) {
+		runLive(req, p, bf.RunOptions{Input: input}, time.Minute, bf.UTF8Output)
+		return &dgo.MessageEmbed{Title: "Execution successful"}, nil
+	})(req)
+
+	if err == nil || !strings.Contains(err.Error(), "input panicked") {
+		t.Errorf("recovered handler error = %v, want the panic of the program", err)
+	}
+	checkEmbed(t, embed, "Internal error", nil)
+}
+
+// panickingResponder is a Responder that panics when replying
+type panickingResponder struct{}
+
+func (panickingResponder) Send(embed *dgo.MessageEmbed, files ...*dgo.File) error {
+	panic("send panicked")
+}
+
+func (panickingResponder) Reply() *dgo.Message {
+	return nil
+}
+
+func TestRecoverReplyPanics(t *testing.T) {
+	before := atomic.LoadInt64(&panicsRecovered)
+
+	req := newRequest([]string{"help"})
+	req.Transport = newFakeTransport()
+	req.Prefix = testPrefix
+	req.Author = &dgo.User{ID: "author", Username: "tester"}
+	req.Raw = "!bf help"
+	req.Responder = panickingResponder{}
+	handleRequest(req)
+
+	if got := atomic.LoadInt64(&panicsRecovered); got != before+1 {
+		t.Errorf("panicsRecovered = %v, want %v", got, before+1)
+	}
+}
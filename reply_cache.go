@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sync"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+// Number of replies remembered to re-run the commands of edited messages
+const replyCacheSize = 1000
+
+// replyCache maps the IDs of the messages with commands to the replies of the bot,
+// forgetting the oldest ones when it holds more than its size
+type replyCache struct {
+	mu      sync.Mutex
+	size    int
+	replies map[string]*dgo.Message
+	// IDs of the command messages, oldest first
+	order []string
+	// onForget is called with the replies forgotten to make room for new ones, if not nil
+	onForget func(reply *dgo.Message)
+}
+
+func newReplyCache(size int, onForget func(reply *dgo.Message)) *replyCache {
+	return &replyCache{size: size, replies: make(map[string]*dgo.Message), onForget: onForget}
+}
+
+// Add remembers the reply to the command in the message with the given ID
+func (c *replyCache) Add(messageID string, reply *dgo.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.replies[messageID]; !ok {
+		c.order = append(c.order, messageID)
+	}
+	c.replies[messageID] = reply
+
+	for len(c.order) > c.size {
+		if c.onForget != nil {
+			c.onForget(c.replies[c.order[0]])
+		}
+		delete(c.replies, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// Get returns the reply to the command in the message with the given ID, if it is remembered
+func (c *replyCache) Get(messageID string) (*dgo.Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reply, ok := c.replies[messageID]
+	return reply, ok
+}
+
+// Remove forgets the reply to the command in the message with the given ID, returning it
+func (c *replyCache) Remove(messageID string) (*dgo.Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reply, ok := c.replies[messageID]
+	if !ok {
+		return nil, false
+	}
+
+	delete(c.replies, messageID)
+	for i, id := range c.order {
+		if id == messageID {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	return reply, true
+}
+
+// Replies to the recent commands sent as messages.
+// The replies forgotten can no longer be deleted with a reaction.
+var replies = newReplyCache(replyCacheSize, func(reply *dgo.Message) {
+	unwatchReactions(reply.ID, deleteEmoji)
+})
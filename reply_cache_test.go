@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+func TestReplyCache(t *testing.T) {
+	var forgotten []string
+	c := newReplyCache(2, func(reply *dgo.Message) { forgotten = append(forgotten, reply.ID) })
+	c.Add("1", &dgo.Message{ID: "reply-1"})
+	c.Add("2", &dgo.Message{ID: "reply-2"})
+	c.Add("1", &dgo.Message{ID: "reply-1b"})
+	c.Add("3", &dgo.Message{ID: "reply-3"})
+
+	if _, ok := c.Get("1"); ok {
+		t.Errorf("Get(1) found the oldest reply, which should have been forgotten")
+	}
+	if want := []string{"reply-1b"}; !reflect.DeepEqual(forgotten, want) {
+		t.Errorf("forgotten replies = %v, want %v", forgotten, want)
+	}
+	for id, want := range map[string]string{"2": "reply-2", "3": "reply-3"} {
+		if reply, ok := c.Get(id); !ok || reply.ID != want {
+			t.Errorf("Get(%v) = %v, %v, want %v", id, reply, ok, want)
+		}
+	}
+}
+
+func TestReplyCacheRemove(t *testing.T) {
+	c := newReplyCache(2, nil)
+	c.Add("1", &dgo.Message{ID: "reply-1"})
+
+	if reply, ok := c.Remove("1"); !ok || reply.ID != "reply-1" {
+		t.Errorf("Remove(1) = %v, %v, want reply-1", reply, ok)
+	}
+	if _, ok := c.Remove("1"); ok {
+		t.Errorf("Remove(1) found the reply twice")
+	}
+
+	c.Add("2", &dgo.Message{ID: "reply-2"})
+	c.Add("3", &dgo.Message{ID: "reply-3"})
+	if _, ok := c.Get("2"); !ok {
+		t.Errorf("Get(2) did not find the reply, but the removed one should have made room for it")
+	}
+}
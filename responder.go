@@ -0,0 +1,130 @@
+package main
+
+import (
+	"sync"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+// Responder sends the replies to a command, wherever the command came from.
+// The first reply creates the response, and the following ones replace it,
+// so a command can show its progress and then its final result in the same message.
+type Responder interface {
+	// Send sends the embed and files as the reply, or replaces the reply if one was already sent
+	Send(embed *dgo.MessageEmbed, files ...*dgo.File) error
+	// Reply returns the message holding the reply, or nil if nothing was sent yet
+	Reply() *dgo.Message
+}
+
+// messageResponder replies to commands sent as messages with the bot prefix
+type messageResponder struct {
+	transport Transport
+	channelID string
+
+	mu    sync.Mutex
+	reply *dgo.Message
+	// clearFiles tells if the files of the reply must be removed by the next edit
+	clearFiles bool
+}
+
+func newMessageResponder(t Transport, m *dgo.Message) *messageResponder {
+	return &messageResponder{
+		transport: t,
+		channelID: m.ChannelID,
+	}
+}
+
+// editingMessageResponder creates a responder that replaces an existing reply,
+// including its files, to answer to a command again
+func editingMessageResponder(t Transport, reply *dgo.Message) *messageResponder {
+	return &messageResponder{
+		transport:  t,
+		channelID:  reply.ChannelID,
+		reply:      reply,
+		clearFiles: true,
+	}
+}
+
+func (r *messageResponder) Send(embed *dgo.MessageEmbed, files ...*dgo.File) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var err error
+	if r.reply == nil {
+		r.reply, err = r.transport.ChannelMessageSendComplex(r.channelID, &dgo.MessageSend{
+			Embeds: []*dgo.MessageEmbed{embed},
+			Files:  files,
+		})
+	} else {
+		edit := &dgo.MessageEdit{
+			ID:      r.reply.ID,
+			Channel: r.channelID,
+			Embeds:  []*dgo.MessageEmbed{embed},
+			Files:   files,
+		}
+		// New files replace the ones of the previous reply, which are kept otherwise
+		if len(files) > 0 || r.clearFiles {
+			edit.Attachments = &[]*dgo.MessageAttachment{}
+			r.clearFiles = false
+		}
+		_, err = r.transport.ChannelMessageEditComplex(edit)
+	}
+
+	return err
+}
+
+func (r *messageResponder) Reply() *dgo.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.reply
+}
+
+// interactionResponder replies to slash commands.
+// The interaction must be acknowledged with deferInteraction before sending replies,
+// which then edit the deferred response.
+type interactionResponder struct {
+	transport   Transport
+	interaction *dgo.Interaction
+
+	mu    sync.Mutex
+	reply *dgo.Message
+}
+
+func newInteractionResponder(t Transport, i *dgo.Interaction) *interactionResponder {
+	return &interactionResponder{
+		transport:   t,
+		interaction: i,
+	}
+}
+
+// deferInteraction acknowledges the interaction, so Discord waits for the command to run
+// instead of failing the interaction after 3 seconds
+func (r *interactionResponder) deferInteraction() error {
+	return r.transport.InteractionRespond(r.interaction, &dgo.InteractionResponse{
+		Type: dgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+}
+
+func (r *interactionResponder) Send(embed *dgo.MessageEmbed, files ...*dgo.File) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg, err := r.transport.InteractionResponseEdit(r.interaction, &dgo.WebhookEdit{
+		Embeds: &[]*dgo.MessageEmbed{embed},
+		Files:  files,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.reply = msg
+	return nil
+}
+
+func (r *interactionResponder) Reply() *dgo.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.reply
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// GuildSettings are the settings of a guild, changed with the config command
+type GuildSettings struct {
+	// Prefix the bot answers to in the guild, instead of the default one if not empty
+	Prefix string `json:"prefix,omitempty"`
+
+	// Limits of the programs, lower than the ones of the config file if set (see execConfig)
+	MaxInstructions int `json:"max_instructions,omitempty"`
+	MaxMemory       int `json:"max_memory,omitempty"`
+	TimeoutSeconds  int `json:"timeout_seconds,omitempty"`
+	// Bits of the memory cells, 8 if not set
+	CellWidth int `json:"cell_width,omitempty"`
+	// What input instructions do once the input has ended (see bf.ParseEOFMode), an error if not set
+	EOF string `json:"eof,omitempty"`
+
+	// Rules restricting who can run the commands and where, by command name (see checkPermissions)
+	Permissions map[string]CommandRule `json:"permissions,omitempty"`
+
+	// Channels the bot answers in, every channel if empty, and channels it ignores (see answersIn)
+	AllowedChannels []string `json:"allowed_channels,omitempty"`
+	DeniedChannels  []string `json:"denied_channels,omitempty"`
+	// Whether the bot answers in direct messages, only set in their settings, which have an empty
+	// guild ID (see answersDirectMessages)
+	DirectMessages *bool `json:"direct_messages,omitempty"`
+}
+
+// clone returns a copy of the settings that shares no maps or slices with them
+func (g GuildSettings) clone() GuildSettings {
+	if g.Permissions != nil {
+		rules := make(map[string]CommandRule, len(g.Permissions))
+		for name, rule := range g.Permissions {
+			rules[name] = rule.clone()
+		}
+		g.Permissions = rules
+	}
+	g.AllowedChannels = append([]string(nil), g.AllowedChannels...)
+	g.DeniedChannels = append([]string(nil), g.DeniedChannels...)
+	if g.DirectMessages != nil {
+		answer := *g.DirectMessages
+		g.DirectMessages = &answer
+	}
+	return g
+}
+
+// settingsStore keeps the settings of every guild, saving them to a JSON file
+type settingsStore struct {
+	// path of the JSON file, or empty to keep the settings in memory only
+	path string
+
+	mu     sync.Mutex
+	guilds map[string]*GuildSettings
+}
+
+// loadSettingsStore loads the settings saved in the JSON file at path, if it exists
+func loadSettingsStore(path string) (*settingsStore, error) {
+	s := newSettingsStore(path)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read the settings: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &s.guilds); err != nil {
+		return nil, fmt.Errorf("could not parse the settings in %v: %v", path, err)
+	}
+	return s, nil
+}
+
+func newSettingsStore(path string) *settingsStore {
+	return &settingsStore{path: path, guilds: make(map[string]*GuildSettings)}
+}
+
+// Get returns a copy of the settings of a guild
+func (s *settingsStore) Get(guildID string) GuildSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if g, ok := s.guilds[guildID]; ok {
+		return g.clone()
+	}
+	return GuildSettings{}
+}
+
+// Update changes the settings of a guild with f and saves them.
+// Nothing changes if f returns an error, which is returned, or if the settings can not be saved.
+func (s *settingsStore) Update(guildID string, f func(g *GuildSettings) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, existed := s.guilds[guildID]
+	g := &GuildSettings{}
+	if existed {
+		*g = old.clone()
+	}
+	if err := f(g); err != nil {
+		return err
+	}
+	s.guilds[guildID] = g
+
+	if err := s.save(); err != nil {
+		if existed {
+			s.guilds[guildID] = old
+		} else {
+			delete(s.guilds, guildID)
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the settings to the file of the store, replacing it at once so a crash while
+// writing does not lose the previous settings
+func (s *settingsStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.guilds, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("could not save the settings: %v", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("could not save the settings: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("could not save the settings: %v", err)
+	}
+	return nil
+}
+
+// Settings of the guilds, loaded from the data directory by main
+var settings = newSettingsStore("")
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSettingsStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "guilds.json")
+
+	s, err := loadSettingsStore(path)
+	if err != nil {
+		t.Fatalf("loadSettingsStore() of a missing file error = %v", err)
+	}
+	if got := s.Get("guild"); !reflect.DeepEqual(got, GuildSettings{}) {
+		t.Errorf("Get() of a new store = %+v, want the defaults", got)
+	}
+
+	err = s.Update("guild", func(g *GuildSettings) error {
+		g.Prefix = "?"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	wantErr := errors.New("invalid")
+	err = s.Update("guild", func(g *GuildSettings) error {
+		g.Prefix = "!"
+		return wantErr
+	})
+	if err != wantErr {
+		t.Errorf("Update() error = %v, want %v", err, wantErr)
+	}
+
+	loaded, err := loadSettingsStore(path)
+	if err != nil {
+		t.Fatalf("loadSettingsStore() error = %v", err)
+	}
+	if got := loaded.Get("guild").Prefix; got != "?" {
+		t.Errorf("saved prefix = %q, want %q", got, "?")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	bf "brainfuck-discord-bot/brainfuck"
+	"fmt"
+	"strings"
+
+	dgo "github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+var shareCmd = &Command{
+	Name:        "share",
+	Description: "Shares a program with a code anyone can run it with",
+	Details: "Replies with a code like `#3f2a9c` that runs the program with `run #3f2a9c` in any server, and shows it with `show #3f2a9c`. " +
+		"The code comes from the contents of the program, so sharing the same program again gives the same code.\n" +
+		"The program can be given in a code block or as an attached `.bf` file.",
+	Args: argSchema{Min: 1, Max: 1},
+	Params: []Param{
+		{Name: "program", Description: "The Brainfuck program to share, unless attached as a .bf file", Required: true},
+	},
+	Attachment:        ".bf file with the program",
+	ProgramAttachment: true,
+	Handler:           shareCommand,
+}
+
+// isShareCode tells if the argument refers to a shared program, like `#3f2a9c`, instead of a snippet
+func isShareCode(arg string) bool {
+	return strings.HasPrefix(arg, "#")
+}
+
+// findShare gets the shared program with the code of the argument, or returns the embed to reply
+// with if there is none
+func findShare(req *Request, arg string) (Share, *dgo.MessageEmbed, error) {
+	sh, err := shares.Get(strings.ToLower(strings.TrimPrefix(arg, "#")))
+	if err == errShareNotFound {
+		err = fmt.Errorf("there is no shared program with the code `%v`", arg)
+		return sh, &dgo.MessageEmbed{
+			Title:       "Unknown code",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+	if err != nil {
+		return sh, databaseErrorEmbed(err), err
+	}
+	return sh, nil, nil
+}
+
+func shareCommand(req *Request) (*dgo.MessageEmbed, error) {
+	// Surrounding whitespace, like the newlines of code blocks, does not change the code
+	program := strings.TrimSpace(req.Args[0])
+
+	if len(program) > maxSnippetSize {
+		err := fmt.Errorf("the program has %v bytes, but shared programs can have at most %v", len(program), maxSnippetSize)
+		return &dgo.MessageEmbed{
+			Title:       "Invalid arguments",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	if _, err := bf.Compile(program); err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Compilation Error",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, fmt.Errorf("compilation error: %v", err)
+	}
+
+	sh, err := shares.Share(program, req.Author.ID)
+	if err != nil {
+		return databaseErrorEmbed(err), err
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Program shared",
+		Description: fmt.Sprintf("Anyone can run it in any server with `%v run #%v`.", req.Prefix, sh.Code),
+		Color:       SuccessColor,
+		Fields: []*dgo.MessageEmbedField{
+			{Name: "Code", Value: "`#" + sh.Code + "`", Inline: true},
+			{Name: "Author", Value: fmt.Sprintf("<@%v>", sh.AuthorID), Inline: true},
+		},
+		Type: dgo.EmbedTypeArticle,
+	}, nil
+}
+
+// runShare runs a shared program like exec, with the rest of the arguments, and keeps its output
+func runShare(req *Request) (*dgo.MessageEmbed, error) {
+	sh, embed, err := findShare(req, req.Args[0])
+	if embed != nil {
+		return embed, err
+	}
+
+	req.Args = append(req.Args[1:], sh.Program)
+	embed, err = execCommand(req)
+
+	if req.output != nil {
+		if err := shares.RecordOutput(sh.Code, bf.DecodeOutput(req.output, bf.UTF8Output)); err != nil {
+			log.WithError(err).Warn("could not record the output of a shared program")
+		}
+	}
+	return embed, err
+}
+
+// shareEmbed shows a shared program with its metadata
+func shareEmbed(req *Request, sh Share) *dgo.MessageEmbed {
+	fields := []*dgo.MessageEmbedField{
+		req.payloadField("Program", sh.Program, sh.Code+".bf"),
+		{Name: "Author", Value: fmt.Sprintf("<@%v>", sh.AuthorID), Inline: true},
+		{Name: "Created", Value: fmt.Sprintf("<t:%v:f>", sh.Created.Unix()), Inline: true},
+	}
+	if !sh.LastRun.IsZero() {
+		lastOutput := sh.LastOutput
+		if lastOutput == "" {
+			lastOutput = "No output"
+		}
+		fields = append(fields,
+			&dgo.MessageEmbedField{Name: "Last output", Value: lastOutput, Inline: false},
+			&dgo.MessageEmbedField{Name: "Last run", Value: fmt.Sprintf("<t:%v:R>", sh.LastRun.Unix()), Inline: true},
+		)
+	}
+
+	return &dgo.MessageEmbed{
+		Title:  "Shared program #" + sh.Code,
+		Color:  InfoColor,
+		Fields: fields,
+		Type:   dgo.EmbedTypeArticle,
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Length of the shortest share codes, in hex digits. Codes are made longer when they clash.
+const shareCodeLength = 6
+
+// Max characters of the last output kept with a shared program
+const maxShareOutput = 200
+
+// Bucket of the database holding the shared programs, by code
+var sharesBucket = []byte("shares")
+
+var errShareNotFound = errors.New("shared program not found")
+
+// Share is a program shared with a code that anyone can use to run it
+type Share struct {
+	Code     string    `json:"code"`
+	Program  string    `json:"program"`
+	AuthorID string    `json:"author_id"`
+	Created  time.Time `json:"created"`
+	// LastOutput is the beginning of the output of the last successful run, if any
+	LastOutput string    `json:"last_output,omitempty"`
+	LastRun    time.Time `json:"last_run"`
+}
+
+// shareStore keeps the shared programs in the database
+type shareStore struct {
+	db *bolt.DB
+}
+
+// Share stores the program, returning it with its code. The code is the beginning of the hash of
+// the program, so sharing the same program again returns the share that already exists.
+func (s *shareStore) Share(program, authorID string) (Share, error) {
+	sum := sha256.Sum256([]byte(program))
+	hash := hex.EncodeToString(sum[:])
+
+	var sh Share
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(sharesBucket)
+		if err != nil {
+			return err
+		}
+
+		// Codes taken by other programs are made longer until they are unique
+		for n := shareCodeLength; n <= len(hash); n += 2 {
+			code := hash[:n]
+			data := b.Get([]byte(code))
+			if data == nil {
+				sh = Share{Code: code, Program: program, AuthorID: authorID, Created: time.Now().UTC()}
+				if data, err = json.Marshal(sh); err != nil {
+					return err
+				}
+				return b.Put([]byte(code), data)
+			}
+
+			if err := json.Unmarshal(data, &sh); err != nil {
+				return err
+			}
+			if sh.Program == program {
+				return nil
+			}
+		}
+		return errors.New("no free code for the program")
+	})
+	return sh, err
+}
+
+// Get returns the shared program with the given code, or errShareNotFound
+func (s *shareStore) Get(code string) (Share, error) {
+	var sh Share
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sharesBucket)
+		if b == nil {
+			return errShareNotFound
+		}
+		data := b.Get([]byte(code))
+		if data == nil {
+			return errShareNotFound
+		}
+		return json.Unmarshal(data, &sh)
+	})
+	return sh, err
+}
+
+// RecordOutput keeps the beginning of the output of a run of the shared program with the code
+func (s *shareStore) RecordOutput(code, output string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sharesBucket)
+		if b == nil {
+			return errShareNotFound
+		}
+		data := b.Get([]byte(code))
+		if data == nil {
+			return errShareNotFound
+		}
+
+		var sh Share
+		if err := json.Unmarshal(data, &sh); err != nil {
+			return err
+		}
+		sh.LastOutput = truncate(output, maxShareOutput)
+		sh.LastRun = time.Now().UTC()
+
+		data, err := json.Marshal(sh)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(code), data)
+	})
+}
+
+// Programs shared by the users, opened by main
+var shares *shareStore
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	dgo "github.com/bwmarrin/discordgo"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestShareStore(t *testing.T) {
+	db, err := openDatabase(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("openDatabase() error = %v", err)
+	}
+	defer db.Close()
+	s := &shareStore{db: db}
+
+	sh, err := s.Share(",[.,]", "a")
+	if err != nil || len(sh.Code) != shareCodeLength {
+		t.Fatalf("Share() = %+v, %v, want a code of %v digits", sh, err, shareCodeLength)
+	}
+	again, err := s.Share(",[.,]", "b")
+	if err != nil || again.Code != sh.Code || again.AuthorID != "a" {
+		t.Errorf("Share() of the same program = %+v, %v, want the first share %+v", again, err, sh)
+	}
+
+	// Another program taking the code of a program makes the code of the program longer
+	sum := sha256.Sum256([]byte("+"))
+	hash := hex.EncodeToString(sum[:])
+	err = db.Update(func(tx *bolt.Tx) error {
+		data, _ := json.Marshal(Share{Code: hash[:shareCodeLength], Program: "-"})
+		return tx.Bucket(sharesBucket).Put([]byte(hash[:shareCodeLength]), data)
+	})
+	if err != nil {
+		t.Fatalf("could not store the clashing share: %v", err)
+	}
+	clashing, err := s.Share("+", "a")
+	if err != nil || clashing.Code != hash[:shareCodeLength+2] {
+		t.Errorf("Share() of a clashing program = %+v, %v, want the code %v", clashing, err, hash[:shareCodeLength+2])
+	}
+
+	if err := s.RecordOutput(sh.Code, "hello"); err != nil {
+		t.Fatalf("RecordOutput() error = %v", err)
+	}
+	got, err := s.Get(sh.Code)
+	if err != nil || got.LastOutput != "hello" || got.LastRun.IsZero() {
+		t.Errorf("Get() = %+v, %v, want the recorded output", got, err)
+	}
+
+	if _, err := s.Get("000000"); err != errShareNotFound {
+		t.Errorf("Get() of an unknown code error = %v, want %v", err, errShareNotFound)
+	}
+}
+
+func TestShareCommands(t *testing.T) {
+	transport := newFakeTransport()
+
+	msg := testMessage("!bf share ```bf\n,[.,]\n```")
+	msg.GuildID = "share-guild"
+	handleMessage(transport, testPrefix, msg)
+	embed := transport.Sent()[0].Embeds[0]
+	checkEmbed(t, embed, "Program shared", nil)
+
+	sh, err := shares.Get(embed.Fields[0].Value[2 : 2+shareCodeLength])
+	if err != nil {
+		t.Fatalf("the shared program was not stored: %v", err)
+	}
+
+	// The code works in any server
+	send := func(content string) {
+		t.Helper()
+
+		msg := testMessage(content)
+		msg.GuildID = "other-guild"
+		msg.Author.ID = "other"
+		handleMessage(transport, testPrefix, msg)
+	}
+	last := func() *dgo.MessageEmbed {
+		sent := transport.Sent()
+		return sent[len(sent)-1].Embeds[0]
+	}
+
+	send("!bf show #" + sh.Code)
+	checkEmbed(t, last(), "Shared program #"+sh.Code, map[string]string{"Program": ",[.,]", "Author": "<@author>"})
+
+	send(`!bf run #` + sh.Code + ` 'ok\0'`)
+	checkEmbed(t, last(), "Execution successful", map[string]string{"Output": "ok"})
+
+	send("!bf show #" + sh.Code)
+	checkEmbed(t, last(), "Shared program #"+sh.Code, map[string]string{"Last output": "ok"})
+
+	// The output is kept as the program wrote it, without the markers of the reply
+	send(`!bf run #` + sh.Code + ` 'ok\n\0'`)
+	checkEmbed(t, last(), "Execution successful", map[string]string{"Output": "ok\n<EOF>"})
+	send("!bf show #" + sh.Code)
+	checkEmbed(t, last(), "Shared program #"+sh.Code, map[string]string{"Last output": "ok\n"})
+
+	send(`!bf run #` + sh.Code + ` 0`)
+	checkEmbed(t, last(), "Execution successful", map[string]string{"Output": "No output"})
+	send("!bf show #" + sh.Code)
+	checkEmbed(t, last(), "Shared program #"+sh.Code, map[string]string{"Last output": "No output"})
+
+	send("!bf run #000000")
+	checkEmbed(t, last(), "Unknown code", nil)
+	send("!bf share +]")
+	checkEmbed(t, last(), "Compilation Error", nil)
+}
@@ -2,42 +2,35 @@ package main
 
 import (
 	bf "brainfuck-discord-bot/brainfuck"
-	"fmt"
 
 	dgo "github.com/bwmarrin/discordgo"
 )
 
-func validateShortenArgs(args ...string) (bool, error) {
-	n := len(args)
-	if n != 2 {
-		return false, fmt.Errorf("wrong number of arguments to shorten: expected 1 `shorten <program>`, but got %v", n-1)
-	}
-	return true, nil
+var shortenCmd = &Command{
+	Name:        "shorten",
+	Aliases:     []string{"short"},
+	Description: "Creates a shorter version of the program",
+	Details:     "The program can be given in a code block or as an attached `.bf` file.",
+	Args:        argSchema{Min: 1, Max: 1},
+	Params: []Param{
+		{Name: "program", Description: "The Brainfuck program to shorten, unless attached as a .bf file", Required: true},
+	},
+	Attachment:        ".bf file with the program",
+	ProgramAttachment: true,
+	Handler:           shortenCommand,
 }
 
-func shortenCommand(args ...string) (*dgo.MessageEmbed, error) {
-	var err error
-	var ok bool
-
-	if ok, err = validateShortenArgs(args...); !ok {
-		return &dgo.MessageEmbed{
-			Title:       "Invalid number of arguments",
-			Description: err.Error(),
-			Color:       ErrorColor,
-			Type:        dgo.EmbedTypeArticle,
-		}, err
-	}
-
-	program := args[1]
+func shortenCommand(req *Request) (*dgo.MessageEmbed, error) {
+	program := req.Args[0]
 	shortened := bf.Shorten(program)
 
 	return &dgo.MessageEmbed{
 		Color: SuccessColor,
 		Fields: []*dgo.MessageEmbedField{
-			{Name: "Original program", Value: program, Inline: false},
-			{Name: "Short version", Value: shortened, Inline: false},
+			req.payloadField("Original program", program, "original.bf"),
+			req.payloadField("Short version", shortened, "program.bf"),
 		},
 		Type: dgo.EmbedTypeArticle,
-	}, err
+	}, nil
 
 }
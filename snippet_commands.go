@@ -0,0 +1,251 @@
+package main
+
+import (
+	bf "brainfuck-discord-bot/brainfuck"
+	"fmt"
+	"strings"
+	"time"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+var saveCmd = &Command{
+	Name:        "save",
+	Description: "Saves a program with a name, to run it later with run",
+	Details: "Snippets saved in a server can be run, shown and listed by all its members, and snippets saved in direct messages only by you. " +
+		"Only the author of a snippet can replace it.\n" +
+		fmt.Sprintf("Names have up to %v lowercase letters, digits, dashes and underscores, and programs up to %v bytes. ", maxSnippetName, maxSnippetSize) +
+		"The program can be given in a code block or as an attached `.bf` file.",
+	Args: argSchema{Min: 2, Max: 2},
+	Params: []Param{
+		{Name: "name", Description: "The name of the snippet", Required: true},
+		{Name: "program", Description: "The Brainfuck program to save, unless attached as a .bf file", Required: true},
+	},
+	Attachment:        ".bf file with the program",
+	ProgramAttachment: true,
+	Handler:           saveCommand,
+}
+
+var runCmd = &Command{
+	Name:        "run",
+	Description: "Runs a saved snippet or a shared program",
+	Details:     "Takes the name of a snippet, or the code of a shared program like `#3f2a9c`, and the same input and options as exec, see `help exec`.",
+	Args:        argSchema{Min: 1, Max: 2},
+	Params: []Param{
+		{Name: "name", Description: "The name of the snippet, or the code of a shared program like #3f2a9c", Required: true},
+		{Name: "input", Description: "Numbers and quoted strings fed to the program, like 65,0x42,'abc'"},
+	},
+	Options:      execCmd.Options,
+	Attachment:   "Text file whose bytes are used as the input",
+	RunsPrograms: true,
+	Handler:      runSnippetCommand,
+}
+
+var listCmd = &Command{
+	Name:        "list",
+	Description: "Lists the saved snippets",
+	Args:        argSchema{Min: 0, Max: 0},
+	Handler:     listCommand,
+}
+
+var showCmd = &Command{
+	Name:        "show",
+	Description: "Shows the program of a saved snippet or a shared program",
+	Args:        argSchema{Min: 1, Max: 1},
+	Params: []Param{
+		{Name: "name", Description: "The name of the snippet, or the code of a shared program like #3f2a9c", Required: true},
+	},
+	Handler: showCommand,
+}
+
+var deleteCmd = &Command{
+	Name:        "delete",
+	Description: "Deletes a saved snippet",
+	Details:     "Only the author of a snippet and the administrators of the server can delete it.",
+	Args:        argSchema{Min: 1, Max: 1},
+	Params: []Param{
+		{Name: "name", Description: "The name of the snippet", Required: true},
+	},
+	Handler: deleteCommand,
+}
+
+// validateName checks that a name can be used for something users create, like a snippet
+func validateName(what, name string) error {
+	if name == "" || len(name) > maxSnippetName {
+		return fmt.Errorf("the name of a %v must have between 1 and %v characters", what, maxSnippetName)
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return fmt.Errorf("the name of a %v can only have lowercase letters, digits, dashes and underscores, but `%v` has `%c`", what, name, c)
+		}
+	}
+	return nil
+}
+
+// findSnippet gets the snippet of the request with the given name, or returns the embed to reply
+// with if there is none
+func findSnippet(req *Request, name string) (Snippet, *dgo.MessageEmbed, error) {
+	sn, err := snippets.Get(snippetScope(req), strings.ToLower(name))
+	if err == errSnippetNotFound {
+		err = fmt.Errorf("there is no snippet named `%v`", name)
+		return sn, &dgo.MessageEmbed{
+			Title:       "Unknown snippet",
+			Description: err.Error() + fmt.Sprintf("\nType `%v list` to see the saved snippets", req.Prefix),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+	if err != nil {
+		return sn, databaseErrorEmbed(err), err
+	}
+	return sn, nil, nil
+}
+
+func saveCommand(req *Request) (*dgo.MessageEmbed, error) {
+	name := strings.ToLower(req.Args[0])
+	program := req.Args[1]
+
+	err := validateName("snippet", name)
+	if err == nil && len(program) > maxSnippetSize {
+		err = fmt.Errorf("the program has %v bytes, but snippets can have at most %v", len(program), maxSnippetSize)
+	}
+	if err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Invalid arguments",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+
+	if _, err := bf.Compile(program); err != nil {
+		return &dgo.MessageEmbed{
+			Title:       "Compilation Error",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, fmt.Errorf("compilation error: %v", err)
+	}
+
+	err = snippets.Save(snippetScope(req), Snippet{
+		Name:     name,
+		Program:  program,
+		AuthorID: req.Author.ID,
+		Created:  time.Now().UTC(),
+	})
+	if _, taken := err.(*snippetTakenError); taken || err == errSnippetLimit {
+		return &dgo.MessageEmbed{
+			Title:       "Could not save the snippet",
+			Description: err.Error(),
+			Color:       ErrorColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, err
+	}
+	if err != nil {
+		return databaseErrorEmbed(err), err
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Snippet saved",
+		Description: fmt.Sprintf("Type `%v run %v` to run it.", req.Prefix, name),
+		Color:       SuccessColor,
+		Type:        dgo.EmbedTypeArticle,
+	}, nil
+}
+
+// runSnippetCommand runs a snippet or a shared program like exec runs a program, with the rest
+// of the arguments
+func runSnippetCommand(req *Request) (*dgo.MessageEmbed, error) {
+	if isShareCode(req.Args[0]) {
+		return runShare(req)
+	}
+
+	sn, embed, err := findSnippet(req, req.Args[0])
+	if embed != nil {
+		return embed, err
+	}
+
+	req.Args = append(req.Args[1:], sn.Program)
+	return execCommand(req)
+}
+
+func listCommand(req *Request) (*dgo.MessageEmbed, error) {
+	list, err := snippets.List(snippetScope(req))
+	if err != nil {
+		return databaseErrorEmbed(err), err
+	}
+
+	if len(list) == 0 {
+		return &dgo.MessageEmbed{
+			Title:       "Snippets",
+			Description: fmt.Sprintf("There are no snippets yet. Type `%v help save` to learn how to save one.", req.Prefix),
+			Color:       InfoColor,
+			Type:        dgo.EmbedTypeArticle,
+		}, nil
+	}
+
+	var lines strings.Builder
+	for _, sn := range list {
+		fmt.Fprintf(&lines, "`%v` by <@%v> (%v bytes)\n", sn.Name, sn.AuthorID, len(sn.Program))
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Snippets",
+		Description: fmt.Sprintf("Type `%v run <name>` to run a snippet.", req.Prefix),
+		Color:       InfoColor,
+		Fields: []*dgo.MessageEmbedField{
+			req.pagedField("Saved snippets", lines.String()),
+		},
+		Type: dgo.EmbedTypeArticle,
+	}, nil
+}
+
+func showCommand(req *Request) (*dgo.MessageEmbed, error) {
+	if isShareCode(req.Args[0]) {
+		sh, embed, err := findShare(req, req.Args[0])
+		if embed != nil {
+			return embed, err
+		}
+		return shareEmbed(req, sh), nil
+	}
+
+	sn, embed, err := findSnippet(req, req.Args[0])
+	if embed != nil {
+		return embed, err
+	}
+
+	return &dgo.MessageEmbed{
+		Title: "Snippet " + sn.Name,
+		Color: InfoColor,
+		Fields: []*dgo.MessageEmbedField{
+			req.payloadField("Program", sn.Program, sn.Name+".bf"),
+			{Name: "Author", Value: fmt.Sprintf("<@%v>", sn.AuthorID), Inline: true},
+			{Name: "Created", Value: fmt.Sprintf("<t:%v:f>", sn.Created.Unix()), Inline: true},
+		},
+		Type: dgo.EmbedTypeArticle,
+	}, nil
+}
+
+func deleteCommand(req *Request) (*dgo.MessageEmbed, error) {
+	sn, embed, err := findSnippet(req, req.Args[0])
+	if embed != nil {
+		return embed, err
+	}
+
+	if sn.AuthorID != req.Author.ID {
+		if embed, err := requireAdmin(req, "Deleting the snippets of other users"); embed != nil {
+			return embed, err
+		}
+	}
+
+	if err := snippets.Delete(snippetScope(req), sn.Name); err != nil && err != errSnippetNotFound {
+		return databaseErrorEmbed(err), err
+	}
+
+	return &dgo.MessageEmbed{
+		Title:       "Snippet deleted",
+		Description: fmt.Sprintf("The snippet %v was deleted.", sn.Name),
+		Color:       SuccessColor,
+		Type:        dgo.EmbedTypeArticle,
+	}, nil
+}
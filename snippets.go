@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Max length of the names of snippets and challenges
+const maxSnippetName = 32
+
+// Max size of the program of a snippet, in bytes
+const maxSnippetSize = 10_000
+
+// Max number of snippets a user can save in a scope
+const maxSnippetsPerUser = 50
+
+// Bucket of the database holding the snippets, in a nested bucket per scope
+var snippetsBucket = []byte("snippets")
+
+var (
+	errSnippetNotFound = errors.New("snippet not found")
+	errSnippetLimit    = fmt.Errorf("you can save at most %v snippets here, delete some to make room", maxSnippetsPerUser)
+)
+
+// snippetTakenError is returned when saving a snippet with the name of a snippet of another user
+type snippetTakenError struct {
+	Name     string
+	AuthorID string
+}
+
+func (e *snippetTakenError) Error() string {
+	return fmt.Sprintf("there is already a snippet named `%v` saved by <@%v>", e.Name, e.AuthorID)
+}
+
+// Snippet is a program saved by a user with a name
+type Snippet struct {
+	Name     string    `json:"name"`
+	Program  string    `json:"program"`
+	AuthorID string    `json:"author_id"`
+	Created  time.Time `json:"created"`
+}
+
+// snippetStore keeps the snippets in the database. Snippets are saved in a scope: the guild they
+// are saved in, visible to all its members, or the user who saved them in direct messages.
+type snippetStore struct {
+	db *bolt.DB
+}
+
+// snippetScope returns the scope of the snippets of a request
+func snippetScope(req *Request) string {
+	if req.GuildID == "" {
+		return "user:" + req.Author.ID
+	}
+	return "guild:" + req.GuildID
+}
+
+// Save saves the snippet in the scope. A snippet with the same name is replaced if it has the
+// same author, and an error is returned if it belongs to someone else.
+func (s *snippetStore) Save(scope string, sn Snippet) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		root, err := tx.CreateBucketIfNotExists(snippetsBucket)
+		if err != nil {
+			return err
+		}
+		b, err := root.CreateBucketIfNotExists([]byte(scope))
+		if err != nil {
+			return err
+		}
+
+		owned := 0
+		var old *Snippet
+		err = b.ForEach(func(k, v []byte) error {
+			var other Snippet
+			if err := json.Unmarshal(v, &other); err != nil {
+				return err
+			}
+			if other.Name == sn.Name {
+				old = &other
+			} else if other.AuthorID == sn.AuthorID {
+				owned++
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if old != nil && old.AuthorID != sn.AuthorID {
+			return &snippetTakenError{Name: sn.Name, AuthorID: old.AuthorID}
+		}
+		if owned >= maxSnippetsPerUser {
+			return errSnippetLimit
+		}
+
+		data, err := json.Marshal(sn)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(sn.Name), data)
+	})
+}
+
+// Get returns the snippet of the scope with the given name, or errSnippetNotFound
+func (s *snippetStore) Get(scope, name string) (Snippet, error) {
+	var sn Snippet
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := scopeBucket(tx, scope)
+		if b == nil {
+			return errSnippetNotFound
+		}
+		data := b.Get([]byte(name))
+		if data == nil {
+			return errSnippetNotFound
+		}
+		return json.Unmarshal(data, &sn)
+	})
+	return sn, err
+}
+
+// List returns the snippets of the scope, sorted by name
+func (s *snippetStore) List(scope string) ([]Snippet, error) {
+	var res []Snippet
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := scopeBucket(tx, scope)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var sn Snippet
+			if err := json.Unmarshal(v, &sn); err != nil {
+				return err
+			}
+			res = append(res, sn)
+			return nil
+		})
+	})
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, err
+}
+
+// Delete deletes the snippet of the scope with the given name, or returns errSnippetNotFound
+func (s *snippetStore) Delete(scope, name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := scopeBucket(tx, scope)
+		if b == nil || b.Get([]byte(name)) == nil {
+			return errSnippetNotFound
+		}
+		return b.Delete([]byte(name))
+	})
+}
+
+// scopeBucket returns the bucket of the snippets of a scope, or nil if it has none
+func scopeBucket(tx *bolt.Tx, scope string) *bolt.Bucket {
+	root := tx.Bucket(snippetsBucket)
+	if root == nil {
+		return nil
+	}
+	return root.Bucket([]byte(scope))
+}
+
+// Snippets saved by the users, opened by main
+var snippets *snippetStore
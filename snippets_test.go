@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+func TestSnippetStore(t *testing.T) {
+	db, err := openDatabase(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("openDatabase() error = %v", err)
+	}
+	defer db.Close()
+	s := &snippetStore{db: db}
+
+	if err := s.Save("guild:1", Snippet{Name: "cat", Program: ",[.,]", AuthorID: "a"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := s.Save("guild:1", Snippet{Name: "cat", Program: ",.", AuthorID: "a"}); err != nil {
+		t.Errorf("Save() of the own snippet again error = %v", err)
+	}
+	if err, ok := s.Save("guild:1", Snippet{Name: "cat", Program: "+", AuthorID: "b"}).(*snippetTakenError); !ok {
+		t.Errorf("Save() of the snippet of another user error = %v, want a snippetTakenError", err)
+	}
+
+	if _, err := s.Get("guild:2", "cat"); err != errSnippetNotFound {
+		t.Errorf("Get() from another scope error = %v, want %v", err, errSnippetNotFound)
+	}
+	sn, err := s.Get("guild:1", "cat")
+	if err != nil || sn.Program != ",." || sn.AuthorID != "a" {
+		t.Errorf("Get() = %+v, %v, want the replaced snippet of a", sn, err)
+	}
+
+	for i := 0; i < maxSnippetsPerUser; i++ {
+		err := s.Save("guild:1", Snippet{Name: strings.Repeat("x", i+1), Program: "+", AuthorID: "b"})
+		if err != nil {
+			t.Fatalf("Save() of snippet %v error = %v", i, err)
+		}
+	}
+	if err := s.Save("guild:1", Snippet{Name: "one-more", Program: "+", AuthorID: "b"}); err != errSnippetLimit {
+		t.Errorf("Save() over the limit error = %v, want %v", err, errSnippetLimit)
+	}
+
+	list, err := s.List("guild:1")
+	if err != nil || len(list) != maxSnippetsPerUser+1 || list[0].Name != "cat" {
+		t.Errorf("List() = %v snippets starting with %+v, %v", len(list), list[0], err)
+	}
+
+	if err := s.Delete("guild:1", "cat"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if err := s.Delete("guild:1", "cat"); err != errSnippetNotFound {
+		t.Errorf("Delete() of a deleted snippet error = %v, want %v", err, errSnippetNotFound)
+	}
+}
+
+func TestSnippetCommands(t *testing.T) {
+	transport := newFakeTransport()
+	transport.permissions["admin"] = dgo.PermissionManageServer
+
+	send := func(content, authorID string) *dgo.MessageEmbed {
+		t.Helper()
+
+		msg := testMessage(content)
+		msg.GuildID = "snippets-guild"
+		msg.Author.ID = authorID
+
+		handleMessage(transport, testPrefix, msg)
+		sent := transport.Sent()
+		return sent[len(sent)-1].Embeds[0]
+	}
+
+	checkEmbed(t, send("!bf list", "author"), "Snippets", nil)
+	checkEmbed(t, send("!bf save Bad! +", "author"), "Invalid arguments", nil)
+	checkEmbed(t, send("!bf save echo +]", "author"), "Compilation Error", nil)
+	checkEmbed(t, send("!bf save Echo `,[.,]`", "author"), "Snippet saved", nil)
+	checkEmbed(t, send("!bf save echo +", "other"), "Could not save the snippet", nil)
+
+	checkEmbed(t, send(`!bf run echo 'hi\0'`, "other"), "Execution successful", map[string]string{"Output": "hi"})
+	checkEmbed(t, send(`!bf run echo --out=dec --input=7,0`, "other"), "Execution successful", map[string]string{"Output": "7"})
+	checkEmbed(t, send("!bf run nope", "author"), "Unknown snippet", nil)
+	checkEmbed(t, send("!bf show echo", "other"), "Snippet echo", map[string]string{"Program": ",[.,]", "Author": "<@author>"})
+	checkEmbed(t, send("!bf list", "other"), "Snippets", map[string]string{"Saved snippets": "`echo` by <@author> (5 bytes)\n"})
+
+	checkEmbed(t, send("!bf delete echo", "other"), "Permission denied", nil)
+	checkEmbed(t, send("!bf delete echo", "admin"), "Snippet deleted", nil)
+	checkEmbed(t, send("!bf show echo", "author"), "Unknown snippet", nil)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	dgo "github.com/bwmarrin/discordgo"
+)
+
+// Transport is the part of the Discord API the bot uses to reply to commands.
+// *dgo.Session implements it, and tests replace it with an in-memory fake.
+type Transport interface {
+	ChannelMessageSendComplex(channelID string, data *dgo.MessageSend, options ...dgo.RequestOption) (*dgo.Message, error)
+	ChannelMessageEditComplex(m *dgo.MessageEdit, options ...dgo.RequestOption) (*dgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string, options ...dgo.RequestOption) error
+	MessageReactionAdd(channelID, messageID, emojiID string, options ...dgo.RequestOption) error
+	MessageReactionRemove(channelID, messageID, emojiID, userID string, options ...dgo.RequestOption) error
+	UserChannelPermissions(userID, channelID string, fetchOptions ...dgo.RequestOption) (int64, error)
+	InteractionRespond(interaction *dgo.Interaction, resp *dgo.InteractionResponse, options ...dgo.RequestOption) error
+	InteractionResponseEdit(interaction *dgo.Interaction, newresp *dgo.WebhookEdit, options ...dgo.RequestOption) (*dgo.Message, error)
+}
+
+var _ Transport = (*dgo.Session)(nil)
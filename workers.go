@@ -0,0 +1,54 @@
+package main
+
+import (
+	"runtime"
+
+	"github.com/spf13/viper"
+)
+
+// workerPool runs a bounded number of tasks at a time, with a bounded queue of tasks
+// waiting for their turn
+type workerPool struct {
+	// Holds a token for every task running
+	running chan struct{}
+	// Holds a token for every task running or waiting
+	admitted chan struct{}
+}
+
+func newWorkerPool(workers, queue int) *workerPool {
+	return &workerPool{
+		running:  make(chan struct{}, workers),
+		admitted: make(chan struct{}, workers+queue),
+	}
+}
+
+// Do runs f once a worker is free, returning false without running it if the queue is full
+func (p *workerPool) Do(f func()) bool {
+	select {
+	case p.admitted <- struct{}{}:
+	default:
+		return false
+	}
+	defer func() { <-p.admitted }()
+
+	p.running <- struct{}{}
+	defer func() { <-p.running }()
+
+	f()
+	return true
+}
+
+// Default number of commands waiting for a worker to run their program, see setupLimits
+const defaultExecutionQueue = 20
+
+// Workers running the programs of commands
+var executions = newWorkerPool(runtime.NumCPU(), defaultExecutionQueue)
+
+// setupLimits configures the rate limits, the workers running programs and the limits of the
+// programs from the config
+func setupLimits() {
+	setupExecCeilings()
+	userLimiter = newRateLimiter(viper.GetInt("rate_limit.user.burst"), viper.GetFloat64("rate_limit.user.per_minute"))
+	guildLimiter = newRateLimiter(viper.GetInt("rate_limit.guild.burst"), viper.GetFloat64("rate_limit.guild.per_minute"))
+	executions = newWorkerPool(viper.GetInt("executions.workers"), viper.GetInt("executions.queue"))
+}
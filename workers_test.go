@@ -0,0 +1,40 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestWorkerPool(t *testing.T) {
+	p := newWorkerPool(1, 1)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan bool, 2)
+
+	// The first task takes the worker, and the second one waits in the queue
+	go func() {
+		done <- p.Do(func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+	go func() { done <- p.Do(func() {}) }()
+
+	// Wait for the second task to be queued
+	for len(p.admitted) < 2 {
+		runtime.Gosched()
+	}
+
+	if p.Do(func() { t.Errorf("task ran with a full queue") }) {
+		t.Errorf("Do() = true with a full queue, want false")
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if !<-done {
+			t.Errorf("queued task was not run")
+		}
+	}
+}